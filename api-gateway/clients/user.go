@@ -0,0 +1,137 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/api-gateway/models"
+	"social-network/common/proto"
+	"sync"
+	"time"
+)
+
+type introspectionCacheEntry struct {
+	result    models.TokenIntrospection
+	expiresAt time.Time
+}
+
+// UserServiceClient talks to the internal user-service endpoints which are not proxied to the outside
+// and to its gRPC UserService.
+type UserServiceClient struct {
+	baseURL       string
+	internalToken string
+	httpClient    *http.Client
+	users         proto.UserServiceClient
+
+	cacheTTL time.Duration
+	mu       sync.Mutex
+	cache    map[string]introspectionCacheEntry
+}
+
+func NewUserServiceClient(
+	baseURL, internalToken string, users proto.UserServiceClient, cacheTTL time.Duration,
+) *UserServiceClient {
+	return &UserServiceClient{
+		baseURL:       baseURL,
+		internalToken: internalToken,
+		httpClient:    &http.Client{Timeout: 5 * time.Second},
+		users:         users,
+		cacheTTL:      cacheTTL,
+		cache:         make(map[string]introspectionCacheEntry),
+	}
+}
+
+// Introspect asks user-service over gRPC whether the token belongs to an active session.
+// Results are cached for a short time, so a revoked session is rejected after at most cacheTTL.
+func (c *UserServiceClient) Introspect(ctx context.Context, token string) (*models.TokenIntrospection, error) {
+	if result, ok := c.cachedIntrospection(token); ok {
+		return result, nil
+	}
+
+	response, err := c.users.ValidateToken(ctx, &proto.ValidateTokenRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	result := models.TokenIntrospection{
+		Active:        response.Active,
+		TokenType:     response.TokenType,
+		UserID:        uint(response.UserId),
+		Username:      response.Username,
+		SessionID:     uint(response.SessionId),
+		Roles:         response.Roles,
+		EmailVerified: response.EmailVerified,
+		TokenVersion:  uint(response.TokenVersion),
+		Scopes:        response.Scopes,
+		Restricted:    response.Restricted,
+	}
+	c.storeIntrospection(token, result)
+	return &result, nil
+}
+
+// ExportUserData returns everything user-service stores about the user.
+func (c *UserServiceClient) ExportUserData(ctx context.Context, userID int) (*models.UserDataExport, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/internal/users/%d/export", c.baseURL, userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Token", c.internalToken)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user data export failed with status %d", resp.StatusCode)
+	}
+
+	var result models.UserDataExport
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ForgetSessions drops cached introspections of the user's sessions for which revoked returns true,
+// so their tokens are refused at once instead of after cacheTTL.
+// Only this gateway instance forgets them, others still wait for the cache to expire.
+func (c *UserServiceClient) ForgetSessions(userID uint, revoked func(sessionID uint) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for token, entry := range c.cache {
+		if entry.result.UserID == userID && entry.result.SessionID != 0 && revoked(entry.result.SessionID) {
+			delete(c.cache, token)
+		}
+	}
+}
+
+func (c *UserServiceClient) cachedIntrospection(token string) (*models.TokenIntrospection, bool) {
+	if c.cacheTTL <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[token]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	result := entry.result
+	return &result, true
+}
+
+func (c *UserServiceClient) storeIntrospection(token string, result models.TokenIntrospection) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.cache) >= 10000 {
+		for key, entry := range c.cache {
+			if now.After(entry.expiresAt) {
+				delete(c.cache, key)
+			}
+		}
+	}
+	c.cache[token] = introspectionCacheEntry{result: result, expiresAt: now.Add(c.cacheTTL)}
+}
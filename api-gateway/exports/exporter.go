@@ -0,0 +1,250 @@
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"social-network/api-gateway/models"
+	"social-network/common/proto"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+var ErrExportNotReady = errors.New("data export is not ready")
+
+const postsPageSize = 100
+
+// UserDataSource returns the part of the export stored in user-service.
+type UserDataSource interface {
+	ExportUserData(ctx context.Context, userID int) (*models.UserDataExport, error)
+}
+
+// PostSource is the part of the post-service API used to collect the user's posts.
+type PostSource interface {
+	ListPosts(ctx context.Context, in *proto.ListPostsRequest, opts ...grpc.CallOption) (*proto.ListPostsResponse, error)
+}
+
+type job struct {
+	export models.DataExport
+	userID int
+	path   string
+}
+
+// Exporter builds personal data export archives in the background. Neither service knows about the other,
+// so the gateway collects the profile and security events from user-service and the posts from post-service.
+// Jobs are kept in memory, archives are stored in dir and deleted after ttl.
+type Exporter struct {
+	users   UserDataSource
+	posts   PostSource
+	dir     string
+	ttl     time.Duration
+	timeout time.Duration
+	Now     func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+func NewExporter(users UserDataSource, posts PostSource, dir string, ttl time.Duration) *Exporter {
+	return &Exporter{
+		users:   users,
+		posts:   posts,
+		dir:     dir,
+		ttl:     ttl,
+		timeout: 5 * time.Minute,
+		Now:     time.Now,
+		jobs:    make(map[string]*job),
+	}
+}
+
+// Start schedules a new export, if the user already has one in progress it is returned instead.
+func (e *Exporter) Start(userID int) (models.DataExport, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeExpiredLocked()
+	for _, existing := range e.jobs {
+		if existing.userID == userID && existing.export.Status == models.DataExportPending {
+			return existing.export, nil
+		}
+	}
+
+	id, err := newExportID()
+	if err != nil {
+		return models.DataExport{}, err
+	}
+	created := &job{
+		export: models.DataExport{
+			ID:        id,
+			Status:    models.DataExportPending,
+			CreatedAt: e.Now(),
+		},
+		userID: userID,
+	}
+	e.jobs[id] = created
+	go e.build(created)
+	return created.export, nil
+}
+
+// Get returns the export if it belongs to the user and hasn't expired yet.
+func (e *Exporter) Get(userID int, id string) (models.DataExport, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeExpiredLocked()
+	existing, ok := e.jobs[id]
+	if !ok || existing.userID != userID {
+		return models.DataExport{}, false
+	}
+	return existing.export, true
+}
+
+// ArchivePath returns the path of the ready archive, the export has to belong to the user.
+func (e *Exporter) ArchivePath(userID int, id string) (string, bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeExpiredLocked()
+	existing, ok := e.jobs[id]
+	if !ok || existing.userID != userID {
+		return "", false, nil
+	}
+	if existing.export.Status != models.DataExportReady {
+		return "", true, ErrExportNotReady
+	}
+	return existing.path, true, nil
+}
+
+func (e *Exporter) build(j *job) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	path, err := e.writeArchive(ctx, j.userID, j.export.ID)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.Now()
+	j.export.CompletedAt = &now
+	if err != nil {
+		log.Printf("Failed to export data of user %d: %v", j.userID, err)
+		j.export.Status = models.DataExportFailed
+		j.export.Error = "Failed to collect user data"
+		return
+	}
+	expiresAt := now.Add(e.ttl)
+	j.export.Status = models.DataExportReady
+	j.export.ExpiresAt = &expiresAt
+	j.path = path
+}
+
+func (e *Exporter) writeArchive(ctx context.Context, userID int, id string) (string, error) {
+	userData, err := e.users.ExportUserData(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("user-service: %w", err)
+	}
+	posts, err := e.collectPosts(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("post-service: %w", err)
+	}
+
+	if err = os.MkdirAll(e.dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(e.dir, id+".zip")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	archive := zip.NewWriter(file)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", userData.Profile},
+		{"relations.json", userData.Relations},
+		{"security_events.json", userData.Security},
+		{"posts.json", posts},
+	}
+	for _, entry := range files {
+		if err = writeJSON(archive, entry.name, entry.content); err != nil {
+			break
+		}
+	}
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// collectPosts returns all posts of the user including private ones, which are visible to their creator only.
+func (e *Exporter) collectPosts(ctx context.Context, userID int) ([]models.Post, error) {
+	creatorID := strconv.Itoa(userID)
+	posts := []models.Post{}
+	for page := int32(1); ; page++ {
+		response, err := e.posts.ListPosts(ctx, &proto.ListPostsRequest{
+			Page:        page,
+			PageSize:    postsPageSize,
+			RequesterId: creatorID,
+			CreatorId:   creatorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range response.Posts {
+			posts = append(posts, models.PostFromProto(post))
+		}
+		if page >= response.TotalPages {
+			return posts, nil
+		}
+	}
+}
+
+func writeJSON(archive *zip.Writer, name string, content interface{}) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(content)
+}
+
+func (e *Exporter) removeExpiredLocked() {
+	now := e.Now()
+	for id, existing := range e.jobs {
+		expired := existing.export.ExpiresAt != nil && now.After(*existing.export.ExpiresAt)
+		// failed exports are kept only for the user to see the error
+		failed := existing.export.Status == models.DataExportFailed && now.After(existing.export.CompletedAt.Add(e.ttl))
+		if !expired && !failed {
+			continue
+		}
+		if existing.path != "" {
+			if err := os.Remove(existing.path); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove data export %s: %v", id, err)
+			}
+		}
+		delete(e.jobs, id)
+	}
+}
+
+func newExportID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"social-network/api-gateway/exports"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exporter *exports.Exporter
+}
+
+func NewExportHandler(exporter *exports.Exporter) *ExportHandler {
+	return &ExportHandler{exporter: exporter}
+}
+
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "userId is required"})
+		return
+	}
+	export, err := h.exporter.Start(userId.(int))
+	if err != nil {
+		log.Printf("Failed to start data export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start data export"})
+		return
+	}
+	c.JSON(http.StatusAccepted, export)
+}
+
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "userId is required"})
+		return
+	}
+	export, ok := h.exporter.Get(userId.(int), c.Param("exportId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data export not found"})
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "userId is required"})
+		return
+	}
+	path, ok, err := h.exporter.ArchivePath(userId.(int), c.Param("exportId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data export not found"})
+		return
+	}
+	if errors.Is(err, exports.ErrExportNotReady) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Data export is not ready"})
+		return
+	}
+	c.FileAttachment(path, "data-export.zip")
+}
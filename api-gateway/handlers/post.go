@@ -6,34 +6,89 @@ import (
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"log"
 	"net/http"
 	"social-network/api-gateway/models"
 	"social-network/common/proto"
+	"social-network/common/relations"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// RelationProvider returns blocks and mutes of a user, which are stored in user-service.
+type RelationProvider interface {
+	GetRelations(ctx context.Context, userID uint64) (*relations.Relations, error)
+}
+
 type PostHandler struct {
-	client proto.PostServiceClient
+	client    proto.PostServiceClient
+	users     proto.UserServiceClient
+	relations RelationProvider
+}
+
+func NewPostHandler(client proto.PostServiceClient, users proto.UserServiceClient, relations RelationProvider) *PostHandler {
+	return &PostHandler{client: client, users: users, relations: relations}
 }
 
-func NewPostHandler(client proto.PostServiceClient) *PostHandler {
-	return &PostHandler{client: client}
+// maxBatchGetUsers is the limit of user-service BatchGetUsers.
+const maxBatchGetUsers = 100
+
+// withAuthors converts the posts and embeds their creators with a single user-service call.
+// Posts are still returned without authors if user-service is unavailable.
+func (h *PostHandler) withAuthors(ctx context.Context, requesterID int, protoPosts ...*proto.Post) []models.Post {
+	posts := make([]models.Post, len(protoPosts))
+	var creatorIDs []uint64
+	seen := make(map[uint64]bool)
+	for i, post := range protoPosts {
+		posts[i] = models.PostFromProto(post)
+		creatorID, err := strconv.ParseUint(post.CreatorId, 10, 64)
+		if err != nil || seen[creatorID] {
+			continue
+		}
+		seen[creatorID] = true
+		creatorIDs = append(creatorIDs, creatorID)
+	}
+	if len(creatorIDs) == 0 {
+		return posts
+	}
+
+	authors := make(map[string]models.Author, len(creatorIDs))
+	for start := 0; start < len(creatorIDs); start += maxBatchGetUsers {
+		end := min(start+maxBatchGetUsers, len(creatorIDs))
+		response, err := h.users.BatchGetUsers(ctx, &proto.BatchGetUsersRequest{
+			Ids:      creatorIDs[start:end],
+			ViewerId: uint64(requesterID),
+		})
+		if err != nil {
+			log.Printf("Error during withAuthors.BatchGetUsers: %v", err)
+			return posts
+		}
+		for _, user := range response.Users {
+			authors[strconv.FormatUint(user.Id, 10)] = models.AuthorFromProto(user)
+		}
+	}
+	for i := range posts {
+		if author, ok := authors[posts[i].CreatorID]; ok {
+			posts[i].Author = &author
+		}
+	}
+	return posts
 }
 
-func convertProtoToPost(p *proto.Post) models.Post {
-	post := models.Post{
-		Title:       p.Title,
-		Description: p.Description,
-		CreatorID:   p.CreatorId,
-		IsPrivate:   p.IsPrivate,
-		Tags:        p.Tags,
-	}
-	post.ID = uint(p.Id)
-	post.CreatedAt = p.CreatedAt.AsTime()
-	post.UpdatedAt = p.UpdatedAt.AsTime()
-	return post
+// blockedByCreator checks whether the creator blocked the requester, post-service checks it as well,
+// but the gateway can answer without calling it. It responds with an error if the check fails.
+func (h *PostHandler) blockedByCreator(ctx context.Context, c *gin.Context, requesterID int, creatorID string) (bool, bool) {
+	creator, err := strconv.ParseUint(creatorID, 10, 64)
+	if err != nil {
+		return false, true
+	}
+	userRelations, err := h.relations.GetRelations(ctx, uint64(requesterID))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to check user relations"})
+		return false, false
+	}
+	return !userRelations.CanView(creator), true
 }
 
 func handleGRPCError(c *gin.Context, err error) {
@@ -84,7 +139,7 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, convertProtoToPost(post))
+	c.JSON(http.StatusCreated, h.withAuthors(ctx, userId.(int), post)[0])
 }
 
 func (h *PostHandler) GetPost(c *gin.Context) {
@@ -115,7 +170,15 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		handleGRPCError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, convertProtoToPost(post))
+	blocked, ok := h.blockedByCreator(ctx, c, userId.(int), post.CreatorId)
+	if !ok {
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	c.JSON(http.StatusOK, h.withAuthors(ctx, userId.(int), post)[0])
 }
 
 func (h *PostHandler) UpdatePost(c *gin.Context) {
@@ -154,7 +217,7 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		handleGRPCError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, convertProtoToPost(post))
+	c.JSON(http.StatusOK, h.withAuthors(ctx, userId.(int), post)[0])
 }
 
 func (h *PostHandler) DeletePost(c *gin.Context) {
@@ -222,17 +285,23 @@ func (h *PostHandler) ListPosts(c *gin.Context) {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	if creatorId != "" {
+		blocked, ok := h.blockedByCreator(ctx, c, userId.(int), creatorId)
+		if !ok {
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+	}
 	response, err := h.client.ListPosts(ctx, grpcReq)
 	if err != nil {
 		handleGRPCError(c, err)
 		return
 	}
-	posts := make([]models.Post, len(response.Posts))
-	for i, post := range response.Posts {
-		posts[i] = convertProtoToPost(post)
-	}
 	c.JSON(http.StatusOK, models.ListPostsResponse{
-		Posts:      posts,
+		Posts:      h.withAuthors(ctx, userId.(int), response.Posts...),
 		TotalCount: response.TotalCount,
 		TotalPages: response.TotalPages,
 		Page:       int32(page),
@@ -2,7 +2,6 @@ package main
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"log"
@@ -10,11 +9,18 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"social-network/api-gateway/clients"
+	"social-network/api-gateway/exports"
 	"social-network/api-gateway/handlers"
 	"social-network/api-gateway/middleware"
+	"social-network/common/jwks"
 	"social-network/common/proto"
+	"social-network/common/relations"
 )
 
 func main() {
@@ -29,10 +35,6 @@ func main() {
 	if postServiceURL == "" {
 		postServiceURL = "post-service:50051"
 	}
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "JWT_SECRET"
-	}
 	conn, err := grpc.NewClient(
 		postServiceURL,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -42,23 +44,164 @@ func main() {
 	}
 	defer conn.Close()
 	postClient := proto.NewPostServiceClient(conn)
-	postHandler := handlers.NewPostHandler(postClient)
-	jwtKey := []byte(jwtSecret)
+	userGRPCURL := os.Getenv("USER_SERVICE_GRPC_URL")
+	if userGRPCURL == "" {
+		userGRPCURL = "user-service:50052"
+	}
+	userConn, err := grpc.NewClient(
+		userGRPCURL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		log.Fatalf("Failed to connect to user service: %v", err)
+	}
+	defer userConn.Close()
+	relationsCacheTTL := 10 * time.Second
+	if value := os.Getenv("RELATIONS_CACHE_TTL"); value != "" {
+		if relationsCacheTTL, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid RELATIONS_CACHE_TTL: %v", err)
+		}
+	}
+	userRelations := relations.NewCachedClient(proto.NewUserRelationServiceClient(userConn), relationsCacheTTL)
+	sessionCacheTTL := 5 * time.Second
+	if value := os.Getenv("SESSION_CACHE_TTL"); value != "" {
+		if sessionCacheTTL, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid SESSION_CACHE_TTL: %v", err)
+		}
+	}
+	userServiceClient := proto.NewUserServiceClient(userConn)
+	userClient := clients.NewUserServiceClient(
+		userServiceURL, os.Getenv("INTERNAL_API_TOKEN"), userServiceClient, sessionCacheTTL)
+	postHandler := handlers.NewPostHandler(postClient, userServiceClient, userRelations)
+	jwksCacheTTL := 5 * time.Minute
+	if value := os.Getenv("JWKS_CACHE_TTL"); value != "" {
+		if jwksCacheTTL, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid JWKS_CACHE_TTL: %v", err)
+		}
+	}
+	signingKeys := jwks.NewClient(userServiceURL+"/.well-known/jwks.json", jwksCacheTTL)
+	authMiddleware := middleware.AuthMiddleware(signingKeys, userClient)
+	accessTokenAuthMiddleware := middleware.AccessTokenAuthMiddleware(signingKeys, userClient)
+	exportsDir := os.Getenv("EXPORTS_DIR")
+	if exportsDir == "" {
+		exportsDir = filepath.Join(os.TempDir(), "exports")
+	}
+	exportTTL := 24 * time.Hour
+	if value := os.Getenv("EXPORT_TTL"); value != "" {
+		if exportTTL, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid EXPORT_TTL: %v", err)
+		}
+	}
+	exportHandler := handlers.NewExportHandler(exports.NewExporter(userClient, postClient, exportsDir, exportTTL))
+	requireVerifiedEmail := middleware.RequireVerifiedEmail(os.Getenv("REQUIRE_VERIFIED_EMAIL_FOR_POSTS") == "true")
+
+	router.GET("/.well-known/jwks.json", proxyHandler(userServiceURL+"/.well-known/jwks.json"))
 
 	api := router.Group("/api")
 	api.POST("/auth/register", proxyHandler(userServiceURL+"/api/auth/register"))
 	api.POST("/auth/login", proxyHandler(userServiceURL+"/api/auth/login"))
-	api.GET("/users/profile", proxyWithAuthHandler(userServiceURL+"/api/users/profile", jwtKey))
-	api.PUT("/users/profile", proxyWithAuthHandler(userServiceURL+"/api/users/profile", jwtKey))
+	api.POST("/auth/login/2fa", proxyHandler(userServiceURL+"/api/auth/login/2fa"))
+	api.POST("/auth/refresh", proxyHandler(userServiceURL+"/api/auth/refresh"))
+	api.POST("/auth/logout", proxyHandler(userServiceURL+"/api/auth/logout"))
+	api.POST("/auth/password/forgot", proxyHandler(userServiceURL+"/api/auth/password/forgot"))
+	api.POST("/auth/password/reset", proxyHandler(userServiceURL+"/api/auth/password/reset"))
+	api.POST("/auth/verify-email", proxyHandler(userServiceURL+"/api/auth/verify-email"))
+	api.GET("/users/profile", proxyWithAuthHandler(userServiceURL+"/api/users/profile", authMiddleware))
+	api.PUT("/users/profile", proxyWithAuthHandler(userServiceURL+"/api/users/profile", authMiddleware))
+	api.PUT("/users/profile/avatar", proxyWithAuthHandler(userServiceURL+"/api/users/profile/avatar", authMiddleware))
+	api.DELETE("/users/profile/avatar", proxyWithAuthHandler(userServiceURL+"/api/users/profile/avatar", authMiddleware))
+	api.GET("/users/avatars/:userId/:file", avatarProxyHandler(userServiceURL))
+	api.DELETE("/users/profile", proxyWithAuthHandler(userServiceURL+"/api/users/profile", authMiddleware))
+	api.PUT("/users/password", proxyWithAuthHandler(userServiceURL+"/api/users/password", authMiddleware))
+	api.GET("/users/security-events",
+		proxyWithAuthHandler(userServiceURL+"/api/users/security-events", authMiddleware))
+	api.GET("/users/tokens", proxyWithAuthHandler(userServiceURL+"/api/users/tokens", authMiddleware))
+	api.POST("/users/tokens", proxyWithAuthHandler(userServiceURL+"/api/users/tokens", authMiddleware))
+	api.GET("/users/profile/deletion",
+		proxyWithAuthHandler(userServiceURL+"/api/users/profile/deletion", authMiddleware))
+	api.POST("/users/profile/deletion/cancel",
+		proxyWithAuthHandler(userServiceURL+"/api/users/profile/deletion/cancel", authMiddleware))
+	api.POST("/users/verify-email/resend",
+		proxyWithAuthHandler(userServiceURL+"/api/users/verify-email/resend", authMiddleware))
+
+	users := api.Group("/users")
+	users.Use(authMiddleware)
+	{
+		userServiceProxy := proxyHandler(userServiceURL)
+		users.GET("/search", userServiceProxy)
+		users.GET("/blocks", userServiceProxy)
+		users.GET("/mutes", userServiceProxy)
+		users.POST("/export", exportHandler.RequestExport)
+		users.GET("/export/:exportId", exportHandler.GetExport)
+		users.GET("/export/:exportId/download", exportHandler.DownloadExport)
+		users.DELETE("/tokens/:tokenId", userServiceProxy)
+		users.GET("/invites", userServiceProxy)
+		users.POST("/invites", userServiceProxy)
+		users.DELETE("/invites/:inviteId", userServiceProxy)
+		users.GET("/sessions", userServiceProxy)
+		users.DELETE("/sessions/others", sessionRevocationHandler(userServiceProxy, func(c *gin.Context) {
+			current := c.GetUint("sessionId")
+			userClient.ForgetSessions(uint(c.GetInt("userId")), func(sessionID uint) bool { return sessionID != current })
+		}))
+		users.DELETE("/sessions/:sessionId", sessionRevocationHandler(userServiceProxy, func(c *gin.Context) {
+			revoked, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+			if err == nil {
+				userClient.ForgetSessions(uint(c.GetInt("userId")), func(sessionID uint) bool { return sessionID == uint(revoked) })
+			}
+		}))
+		users.GET("/:id", userServiceProxy)
+		users.GET("/by-username/:username", userServiceProxy)
+		users.POST("/:id/follow", userServiceProxy)
+		users.DELETE("/:id/follow", userServiceProxy)
+		users.GET("/:id/followers", userServiceProxy)
+		users.GET("/:id/following", userServiceProxy)
+		users.GET("/:id/following/:targetId", userServiceProxy)
+		users.POST("/:id/block", userServiceProxy)
+		users.DELETE("/:id/block", userServiceProxy)
+		users.POST("/:id/mute", userServiceProxy)
+		users.DELETE("/:id/mute", userServiceProxy)
+	}
+
+	twoFactor := api.Group("/users/2fa")
+	twoFactor.Use(authMiddleware)
+	{
+		userServiceProxy := proxyHandler(userServiceURL)
+		twoFactor.POST("/enroll", userServiceProxy)
+		twoFactor.POST("/confirm", userServiceProxy)
+		twoFactor.POST("/disable", userServiceProxy)
+		twoFactor.POST("/recovery-codes", userServiceProxy)
+	}
+
+	// user-service checks the exact permissions, the gateway only keeps other roles away from admin routes
+	admin := api.Group("/admin")
+	admin.Use(authMiddleware)
+	{
+		userServiceProxy := proxyHandler(userServiceURL)
+		requireAdmin := middleware.RequireRoles("admin")
+		requireModerator := middleware.RequireRoles("admin", "moderator")
+		admin.GET("/roles", requireAdmin, userServiceProxy)
+		admin.POST("/users/:id/roles", requireAdmin, userServiceProxy)
+		admin.DELETE("/users/:id/roles/:role", requireAdmin, userServiceProxy)
+		admin.POST("/users/:id/unlock", requireAdmin, userServiceProxy)
+		admin.GET("/lockouts", requireAdmin, userServiceProxy)
+		admin.POST("/users/:id/suspend", requireModerator, userServiceProxy)
+		admin.POST("/users/:id/ban", requireModerator, userServiceProxy)
+		admin.POST("/users/:id/reinstate", requireModerator, userServiceProxy)
+		admin.GET("/users/:id/moderation", requireModerator, userServiceProxy)
+		admin.GET("/users/:id/invites", requireAdmin, userServiceProxy)
+		admin.GET("/security-events", requireAdmin, userServiceProxy)
+	}
 
 	posts := api.Group("/posts")
-	posts.Use(middleware.AuthMiddleware(jwtKey))
+	posts.Use(accessTokenAuthMiddleware)
 	{
-		posts.POST("", postHandler.CreatePost)
-		posts.GET("/:id", postHandler.GetPost)
-		posts.PUT("/:id", postHandler.UpdatePost)
-		posts.DELETE("/:id", postHandler.DeletePost)
-		posts.GET("", postHandler.ListPosts)
+		readPosts := middleware.RequireScope("posts:read")
+		writePosts := middleware.RequireScope("posts:write")
+		posts.POST("", writePosts, requireVerifiedEmail, postHandler.CreatePost)
+		posts.GET("/:id", readPosts, postHandler.GetPost)
+		posts.PUT("/:id", writePosts, postHandler.UpdatePost)
+		posts.DELETE("/:id", writePosts, postHandler.DeletePost)
+		posts.GET("", readPosts, postHandler.ListPosts)
 	}
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -84,13 +227,56 @@ func proxyHandler(targetURL string) gin.HandlerFunc {
 			targetPath = req.URL.Path
 		}
 		req.URL.Path = targetPath
+		// the client can't be trusted to set it, the proxy appends the real client ip instead
+		req.Header.Del("X-Forwarded-For")
 	}
 	return func(context *gin.Context) {
 		proxy.ServeHTTP(context.Writer, context.Request)
 	}
 }
 
-func proxyWithAuthHandler(targetURL string, jwtKey []byte) gin.HandlerFunc {
+// avatarProxyHandler serves avatars without authentication, they are part of public profiles.
+// Every upload gets new URLs, so found avatars never change and can be cached for a year.
+func avatarProxyHandler(targetURL string) gin.HandlerFunc {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		log.Fatalf("Failed to parse URL: %v", err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	originalDirector := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		originalDirector(req)
+		req.Header.Del("X-Forwarded-For")
+		req.Header.Del("Authorization")
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			resp.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+		case http.StatusNotFound:
+			resp.Header.Set("Cache-Control", "public, max-age=60")
+		default:
+			resp.Header.Set("Cache-Control", "no-store")
+		}
+		return nil
+	}
+	return func(c *gin.Context) {
+		proxy.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// sessionRevocationHandler proxies a session revocation and, once user-service confirms it,
+// forgets the cached introspections of the revoked sessions.
+func sessionRevocationHandler(proxy gin.HandlerFunc, forget func(c *gin.Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proxy(c)
+		if c.Writer.Status() == http.StatusOK {
+			forget(c)
+		}
+	}
+}
+
+func proxyWithAuthHandler(targetURL string, authMiddleware gin.HandlerFunc) gin.HandlerFunc {
 	target, err := url.Parse(targetURL)
 	if err != nil {
 		log.Fatalf("Failed to parse URL: %v", err)
@@ -104,26 +290,12 @@ func proxyWithAuthHandler(targetURL string, jwtKey []byte) gin.HandlerFunc {
 			targetPath = req.URL.Path
 		}
 		req.URL.Path = targetPath
+		// the client can't be trusted to set it, the proxy appends the real client ip instead
+		req.Header.Del("X-Forwarded-For")
 	}
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
-			return
-		}
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be 'Bearer {token}'"})
-			c.Abort()
-			return
-		}
-		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		authMiddleware(c)
+		if c.IsAborted() {
 			return
 		}
 		originalPath := c.Request.URL.Path
@@ -1,13 +1,41 @@
 package middleware
 
 import (
+	"context"
+	"crypto"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v4"
+	"log"
 	"net/http"
+	"social-network/api-gateway/models"
+	"social-network/common/jwks"
 	"strings"
 )
 
-func AuthMiddleware(jwtKey []byte) gin.HandlerFunc {
+type TokenIntrospector interface {
+	Introspect(ctx context.Context, token string) (*models.TokenIntrospection, error)
+}
+
+// KeyProvider returns public keys published by user-service in its JWKS.
+type KeyProvider interface {
+	PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// personalAccessTokenPrefix starts personal access tokens issued by user-service, they aren't JWTs.
+const personalAccessTokenPrefix = "snpat_"
+
+// AuthMiddleware accepts only access tokens of user sessions.
+func AuthMiddleware(keys KeyProvider, introspector TokenIntrospector) gin.HandlerFunc {
+	return authMiddleware(keys, introspector, false)
+}
+
+// AccessTokenAuthMiddleware also accepts personal access tokens, so routes using it must check scopes with RequireScope.
+func AccessTokenAuthMiddleware(keys KeyProvider, introspector TokenIntrospector) gin.HandlerFunc {
+	return authMiddleware(keys, introspector, true)
+}
+
+func authMiddleware(keys KeyProvider, introspector TokenIntrospector, allowAccessTokens bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -21,8 +49,30 @@ func AuthMiddleware(jwtKey []byte) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if strings.HasPrefix(parts[1], personalAccessTokenPrefix) {
+			if !allowAccessTokens {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Personal access tokens can't be used for this endpoint"})
+				c.Abort()
+				return
+			}
+			authenticateAccessToken(c, introspector, parts[1])
+			return
+		}
 		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
+			kid, _ := token.Header["kid"].(string)
+			key, err := keys.PublicKey(c.Request.Context(), kid)
+			if err != nil {
+				return nil, err
+			}
+			// the algorithm is taken from the key, never from the token header
+			algorithm, err := jwks.Algorithm(key)
+			if err != nil {
+				return nil, err
+			}
+			if token.Method.Alg() != algorithm {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key, nil
 		})
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
@@ -41,8 +91,66 @@ func AuthMiddleware(jwtKey []byte) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		introspection, err := introspector.Introspect(c.Request.Context(), parts[1])
+		if err != nil {
+			log.Printf("Failed to introspect token: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to validate session"})
+			c.Abort()
+			return
+		}
+		if !introspection.Active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session is revoked or expired"})
+			c.Abort()
+			return
+		}
+		// tokens issued before the latest password change are rejected even if their session is still active
+		tokenVersion, _ := claims["tver"].(float64)
+		if uint(tokenVersion) < introspection.TokenVersion {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token was issued before the password change"})
+			c.Abort()
+			return
+		}
+		if introspection.Restricted {
+			rejectRestricted(c)
+			return
+		}
 		userID := int(userIDFloat)
 		c.Set("userId", userID)
+		// roles come from user-service, not from the claims, so a revoked role isn't trusted until the token expires
+		c.Set("roles", introspection.Roles)
+		c.Set("sessionId", introspection.SessionID)
+		c.Set("emailVerified", introspection.EmailVerified)
 		c.Next()
 	}
 }
+
+func authenticateAccessToken(c *gin.Context, introspector TokenIntrospector, token string) {
+	introspection, err := introspector.Introspect(c.Request.Context(), token)
+	if err != nil {
+		log.Printf("Failed to introspect personal access token: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to validate token"})
+		c.Abort()
+		return
+	}
+	if !introspection.Active || introspection.TokenType != models.TokenTypePersonalAccess {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Personal access token is revoked or expired"})
+		c.Abort()
+		return
+	}
+	if introspection.Restricted {
+		rejectRestricted(c)
+		return
+	}
+	// personal access tokens never carry roles, they only grant their scopes
+	c.Set("userId", int(introspection.UserID))
+	c.Set("roles", []string{})
+	c.Set("scopes", introspection.Scopes)
+	c.Set("emailVerified", introspection.EmailVerified)
+	c.Next()
+}
+
+// rejectRestricted stops suspended and banned users, their tokens may still be unexpired.
+func rejectRestricted(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended or banned"})
+	c.Abort()
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequireVerifiedEmail rejects callers which haven't verified their email yet.
+// If required is false the middleware does nothing, so the policy can be switched by configuration.
+// It must be used after AuthMiddleware.
+func RequireVerifiedEmail(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && !c.GetBool("emailVerified") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email must be verified to perform this action"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
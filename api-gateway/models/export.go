@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UserDataExport is the user-service part of the personal data export, the gateway copies it into the archive as is.
+type UserDataExport struct {
+	Profile   json.RawMessage `json:"profile"`
+	Relations json.RawMessage `json:"relations"`
+	Security  json.RawMessage `json:"security"`
+}
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+type DataExport struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ExpiresAt is set once the archive is ready, after it the archive is deleted
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
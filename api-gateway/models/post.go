@@ -1,6 +1,8 @@
 package models
 
 import (
+	"social-network/common/proto"
+
 	"gorm.io/gorm"
 )
 
@@ -18,6 +20,16 @@ type UpdatePostRequest struct {
 	Tags        []string `json:"tags"`
 }
 
+// Author is the creator of a post as the viewer sees them, resolved from user-service.
+type Author struct {
+	ID          uint              `json:"id"`
+	Username    string            `json:"username"`
+	FirstName   string            `json:"first_name,omitempty"`
+	LastName    string            `json:"last_name,omitempty"`
+	DisplayName string            `json:"display_name,omitempty"`
+	AvatarURLs  map[string]string `json:"avatar_urls,omitempty"`
+}
+
 type Post struct {
 	gorm.Model
 	Title       string   `json:"title"`
@@ -25,6 +37,8 @@ type Post struct {
 	CreatorID   string   `json:"creator_id"`
 	IsPrivate   bool     `json:"is_private"`
 	Tags        []string `json:"tags"`
+	// Author is omitted if the creator can't be resolved, e.g. their account is deleted
+	Author *Author `json:"author,omitempty"`
 }
 
 type ListPostsResponse struct {
@@ -34,3 +48,28 @@ type ListPostsResponse struct {
 	Page       int32  `json:"page"`
 	PageSize   int32  `json:"page_size"`
 }
+
+func PostFromProto(p *proto.Post) Post {
+	post := Post{
+		Title:       p.Title,
+		Description: p.Description,
+		CreatorID:   p.CreatorId,
+		IsPrivate:   p.IsPrivate,
+		Tags:        p.Tags,
+	}
+	post.ID = uint(p.Id)
+	post.CreatedAt = p.CreatedAt.AsTime()
+	post.UpdatedAt = p.UpdatedAt.AsTime()
+	return post
+}
+
+func AuthorFromProto(u *proto.User) Author {
+	return Author{
+		ID:          uint(u.Id),
+		Username:    u.Username,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		DisplayName: u.DisplayName,
+		AvatarURLs:  u.AvatarUrls,
+	}
+}
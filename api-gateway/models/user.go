@@ -0,0 +1,16 @@
+package models
+
+const TokenTypePersonalAccess = "personal_access_token"
+
+type TokenIntrospection struct {
+	Active        bool     `json:"active"`
+	TokenType     string   `json:"token_type"`
+	UserID        uint     `json:"user_id"`
+	Username      string   `json:"username"`
+	SessionID     uint     `json:"session_id"`
+	Roles         []string `json:"roles"`
+	EmailVerified bool     `json:"email_verified"`
+	TokenVersion  uint     `json:"token_version"`
+	Scopes        []string `json:"scopes"`
+	Restricted    bool     `json:"restricted"`
+}
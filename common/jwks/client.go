@@ -0,0 +1,128 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// Client fetches the JWKS document and caches the parsed keys. An unknown kid triggers a refetch,
+// so keys added during rotation are picked up before the cache expires, but not more often than minRefresh.
+// Failed fetches are limited by minRefresh too, so an outage of user-service doesn't make every request wait for it.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// attemptedAt and fetchErr describe the latest fetch, failed ones included
+	attemptedAt time.Time
+	fetchErr    error
+	// fetching is closed when the running fetch finishes, concurrent requests wait for it instead of fetching again
+	fetching chan struct{}
+}
+
+func NewClient(url string, ttl time.Duration) *Client {
+	return &Client{
+		url:        url,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		ttl:        ttl,
+		minRefresh: 10 * time.Second,
+	}
+}
+
+// PublicKey returns the verification key with the kid. The lock isn't held during the fetch,
+// so requests with cached keys never wait for it.
+func (c *Client) PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	now := time.Now()
+	key, found := c.keys[kid]
+	stale := c.keys == nil || now.Sub(c.fetchedAt) > c.ttl
+	if found && !stale {
+		c.mu.Unlock()
+		return key, nil
+	}
+	if fetching := c.fetching; fetching != nil {
+		c.mu.Unlock()
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+	} else if now.Sub(c.attemptedAt) > c.minRefresh {
+		c.refresh(ctx, now)
+	}
+	key, found = c.keys[kid]
+	fetchErr := c.fetchErr
+	c.mu.Unlock()
+
+	// keys from the previous fetch are still better than failing every request
+	if found {
+		return key, nil
+	}
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+}
+
+// refresh fetches the keys without holding the lock, it must be called with the lock held.
+func (c *Client) refresh(ctx context.Context, now time.Time) {
+	fetching := make(chan struct{})
+	c.fetching = fetching
+	c.attemptedAt = now
+	c.mu.Unlock()
+
+	// the result is shared, so a canceled request doesn't fail the fetch for the waiting ones
+	keys, err := c.fetch(context.WithoutCancel(ctx))
+
+	c.mu.Lock()
+	if err == nil {
+		c.keys = keys
+		c.fetchedAt = now
+	}
+	c.fetchErr = err
+	c.fetching = nil
+	close(fetching)
+}
+
+func (c *Client) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS failed with status %d", resp.StatusCode)
+	}
+
+	var set Set
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i := range set.Keys {
+		publicKey, err := set.Keys[i].PublicKey()
+		if err != nil {
+			// keys of unknown types are skipped as RFC 7517 suggests
+			continue
+		}
+		keys[set.Keys[i].Kid] = publicKey
+	}
+	return keys, nil
+}
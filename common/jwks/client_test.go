@@ -0,0 +1,85 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedFetchIsRateLimited(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, time.Minute)
+
+	// concurrent requests share one fetch instead of queueing behind each other
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.PublicKey(context.Background(), "kid")
+			assert.Error(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), hits.Load())
+
+	// the failed attempt counts for minRefresh, so the next requests fail at once
+	started := time.Now()
+	_, err := client.PublicKey(context.Background(), "kid")
+	assert.ErrorContains(t, err, "status 503")
+	assert.Less(t, time.Since(started), 50*time.Millisecond)
+	assert.Equal(t, int32(1), hits.Load())
+
+	client.minRefresh = 0
+	_, err = client.PublicKey(context.Background(), "kid")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestStaleKeysUsedWhenFetchFails(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := NewKey(publicKey)
+	require.NoError(t, err)
+
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(Set{Keys: []Key{key}}))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, time.Millisecond)
+	client.minRefresh = 0
+
+	found, err := client.PublicKey(context.Background(), key.Kid)
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, found)
+
+	failing.Store(true)
+	time.Sleep(5 * time.Millisecond)
+	found, err = client.PublicKey(context.Background(), key.Kid)
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, found)
+
+	_, err = client.PublicKey(context.Background(), "unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported key type")
+
+// Key is a public JSON Web Key (RFC 7517), only RSA and Ed25519 keys are supported.
+type Key struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Set is the document served at /.well-known/jwks.json.
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// Find returns the key with the kid or nil.
+func (s *Set) Find(kid string) *Key {
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i]
+		}
+	}
+	return nil
+}
+
+// Algorithm returns the JWT signing algorithm used with the public key.
+func Algorithm(publicKey crypto.PublicKey) (string, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRS256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", fmt.Errorf("%w: %T", ErrUnsupportedKey, publicKey)
+	}
+}
+
+// NewKey converts the public key to a JWK, kid is the RFC 7638 thumbprint of the key.
+func NewKey(publicKey crypto.PublicKey) (Key, error) {
+	var key Key
+	switch typed := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = Key{
+			Kty: "RSA",
+			N:   encode(typed.N.Bytes()),
+			E:   encode(big.NewInt(int64(typed.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		key = Key{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   encode(typed),
+		}
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, publicKey)
+	}
+	key.Alg, _ = Algorithm(publicKey)
+	key.Use = "sig"
+	key.Kid = key.thumbprint()
+	return key, nil
+}
+
+// PublicKey parses the key back, it fails for unsupported key types and algorithms.
+func (k *Key) PublicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == AlgorithmRS256):
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && (k.Alg == "" || k.Alg == AlgorithmEdDSA):
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: kty=%s crv=%s alg=%s", ErrUnsupportedKey, k.Kty, k.Crv, k.Alg)
+	}
+}
+
+// thumbprint hashes the required members in lexicographic order as RFC 7638 requires.
+func (k *Key) thumbprint() string {
+	var canonical string
+	if k.Kty == "RSA" {
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, k.E, k.N)
+	} else {
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, k.Crv, k.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return encode(sum[:])
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(data)
+}
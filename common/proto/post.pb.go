@@ -566,6 +566,102 @@ func (x *ListPostsResponse) GetTotalPages() int32 {
 	return 0
 }
 
+type EraseUserPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CreatorId     string                 `protobuf:"bytes,1,opt,name=creator_id,json=creatorId,proto3" json:"creator_id,omitempty"`
+	Anonymize     bool                   `protobuf:"varint,2,opt,name=anonymize,proto3" json:"anonymize,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseUserPostsRequest) Reset() {
+	*x = EraseUserPostsRequest{}
+	mi := &file_post_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseUserPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseUserPostsRequest) ProtoMessage() {}
+
+func (x *EraseUserPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseUserPostsRequest.ProtoReflect.Descriptor instead.
+func (*EraseUserPostsRequest) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EraseUserPostsRequest) GetCreatorId() string {
+	if x != nil {
+		return x.CreatorId
+	}
+	return ""
+}
+
+func (x *EraseUserPostsRequest) GetAnonymize() bool {
+	if x != nil {
+		return x.Anonymize
+	}
+	return false
+}
+
+type EraseUserPostsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AffectedCount int64                  `protobuf:"varint,1,opt,name=affected_count,json=affectedCount,proto3" json:"affected_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EraseUserPostsResponse) Reset() {
+	*x = EraseUserPostsResponse{}
+	mi := &file_post_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EraseUserPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EraseUserPostsResponse) ProtoMessage() {}
+
+func (x *EraseUserPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EraseUserPostsResponse.ProtoReflect.Descriptor instead.
+func (*EraseUserPostsResponse) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EraseUserPostsResponse) GetAffectedCount() int64 {
+	if x != nil {
+		return x.AffectedCount
+	}
+	return 0
+}
+
 var File_post_proto protoreflect.FileDescriptor
 
 const file_post_proto_rawDesc = "" +
@@ -624,7 +720,13 @@ const file_post_proto_rawDesc = "" +
 	"\vtotal_count\x18\x02 \x01(\x05R\n" +
 	"totalCount\x12\x1f\n" +
 	"\vtotal_pages\x18\x03 \x01(\x05R\n" +
-	"totalPages2\x9f\x02\n" +
+	"totalPages\"T\n" +
+	"\x15EraseUserPostsRequest\x12\x1d\n" +
+	"\n" +
+	"creator_id\x18\x01 \x01(\tR\tcreatorId\x12\x1c\n" +
+	"\tanonymize\x18\x02 \x01(\bR\tanonymize\"?\n" +
+	"\x16EraseUserPostsResponse\x12%\n" +
+	"\x0eaffected_count\x18\x01 \x01(\x03R\raffectedCount2\xec\x02\n" +
 	"\vPostService\x121\n" +
 	"\n" +
 	"CreatePost\x12\x17.post.CreatePostRequest\x1a\n" +
@@ -636,7 +738,8 @@ const file_post_proto_rawDesc = "" +
 	".post.Post\x12?\n" +
 	"\n" +
 	"DeletePost\x12\x17.post.DeletePostRequest\x1a\x18.post.DeletePostResponse\x12<\n" +
-	"\tListPosts\x12\x16.post.ListPostsRequest\x1a\x17.post.ListPostsResponseB\x0eZ\fcommon/protob\x06proto3"
+	"\tListPosts\x12\x16.post.ListPostsRequest\x1a\x17.post.ListPostsResponse\x12K\n" +
+	"\x0eEraseUserPosts\x12\x1b.post.EraseUserPostsRequest\x1a\x1c.post.EraseUserPostsResponseB\x0eZ\fcommon/protob\x06proto3"
 
 var (
 	file_post_proto_rawDescOnce sync.Once
@@ -650,37 +753,41 @@ func file_post_proto_rawDescGZIP() []byte {
 	return file_post_proto_rawDescData
 }
 
-var file_post_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_post_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_post_proto_goTypes = []any{
-	(*Post)(nil),                  // 0: post.Post
-	(*CreatePostRequest)(nil),     // 1: post.CreatePostRequest
-	(*GetPostRequest)(nil),        // 2: post.GetPostRequest
-	(*UpdatePostRequest)(nil),     // 3: post.UpdatePostRequest
-	(*DeletePostRequest)(nil),     // 4: post.DeletePostRequest
-	(*DeletePostResponse)(nil),    // 5: post.DeletePostResponse
-	(*ListPostsRequest)(nil),      // 6: post.ListPostsRequest
-	(*ListPostsResponse)(nil),     // 7: post.ListPostsResponse
-	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(*Post)(nil),                   // 0: post.Post
+	(*CreatePostRequest)(nil),      // 1: post.CreatePostRequest
+	(*GetPostRequest)(nil),         // 2: post.GetPostRequest
+	(*UpdatePostRequest)(nil),      // 3: post.UpdatePostRequest
+	(*DeletePostRequest)(nil),      // 4: post.DeletePostRequest
+	(*DeletePostResponse)(nil),     // 5: post.DeletePostResponse
+	(*ListPostsRequest)(nil),       // 6: post.ListPostsRequest
+	(*ListPostsResponse)(nil),      // 7: post.ListPostsResponse
+	(*EraseUserPostsRequest)(nil),  // 8: post.EraseUserPostsRequest
+	(*EraseUserPostsResponse)(nil), // 9: post.EraseUserPostsResponse
+	(*timestamppb.Timestamp)(nil),  // 10: google.protobuf.Timestamp
 }
 var file_post_proto_depIdxs = []int32{
-	8, // 0: post.Post.created_at:type_name -> google.protobuf.Timestamp
-	8, // 1: post.Post.updated_at:type_name -> google.protobuf.Timestamp
-	0, // 2: post.ListPostsResponse.posts:type_name -> post.Post
-	1, // 3: post.PostService.CreatePost:input_type -> post.CreatePostRequest
-	2, // 4: post.PostService.GetPost:input_type -> post.GetPostRequest
-	3, // 5: post.PostService.UpdatePost:input_type -> post.UpdatePostRequest
-	4, // 6: post.PostService.DeletePost:input_type -> post.DeletePostRequest
-	6, // 7: post.PostService.ListPosts:input_type -> post.ListPostsRequest
-	0, // 8: post.PostService.CreatePost:output_type -> post.Post
-	0, // 9: post.PostService.GetPost:output_type -> post.Post
-	0, // 10: post.PostService.UpdatePost:output_type -> post.Post
-	5, // 11: post.PostService.DeletePost:output_type -> post.DeletePostResponse
-	7, // 12: post.PostService.ListPosts:output_type -> post.ListPostsResponse
-	8, // [8:13] is the sub-list for method output_type
-	3, // [3:8] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	10, // 0: post.Post.created_at:type_name -> google.protobuf.Timestamp
+	10, // 1: post.Post.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: post.ListPostsResponse.posts:type_name -> post.Post
+	1,  // 3: post.PostService.CreatePost:input_type -> post.CreatePostRequest
+	2,  // 4: post.PostService.GetPost:input_type -> post.GetPostRequest
+	3,  // 5: post.PostService.UpdatePost:input_type -> post.UpdatePostRequest
+	4,  // 6: post.PostService.DeletePost:input_type -> post.DeletePostRequest
+	6,  // 7: post.PostService.ListPosts:input_type -> post.ListPostsRequest
+	8,  // 8: post.PostService.EraseUserPosts:input_type -> post.EraseUserPostsRequest
+	0,  // 9: post.PostService.CreatePost:output_type -> post.Post
+	0,  // 10: post.PostService.GetPost:output_type -> post.Post
+	0,  // 11: post.PostService.UpdatePost:output_type -> post.Post
+	5,  // 12: post.PostService.DeletePost:output_type -> post.DeletePostResponse
+	7,  // 13: post.PostService.ListPosts:output_type -> post.ListPostsResponse
+	9,  // 14: post.PostService.EraseUserPosts:output_type -> post.EraseUserPostsResponse
+	9,  // [9:15] is the sub-list for method output_type
+	3,  // [3:9] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_post_proto_init() }
@@ -694,7 +801,7 @@ func file_post_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_post_proto_rawDesc), len(file_post_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
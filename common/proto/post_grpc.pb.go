@@ -19,11 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PostService_CreatePost_FullMethodName = "/post.PostService/CreatePost"
-	PostService_GetPost_FullMethodName    = "/post.PostService/GetPost"
-	PostService_UpdatePost_FullMethodName = "/post.PostService/UpdatePost"
-	PostService_DeletePost_FullMethodName = "/post.PostService/DeletePost"
-	PostService_ListPosts_FullMethodName  = "/post.PostService/ListPosts"
+	PostService_CreatePost_FullMethodName     = "/post.PostService/CreatePost"
+	PostService_GetPost_FullMethodName        = "/post.PostService/GetPost"
+	PostService_UpdatePost_FullMethodName     = "/post.PostService/UpdatePost"
+	PostService_DeletePost_FullMethodName     = "/post.PostService/DeletePost"
+	PostService_ListPosts_FullMethodName      = "/post.PostService/ListPosts"
+	PostService_EraseUserPosts_FullMethodName = "/post.PostService/EraseUserPosts"
 )
 
 // PostServiceClient is the client API for PostService service.
@@ -35,6 +36,7 @@ type PostServiceClient interface {
 	UpdatePost(ctx context.Context, in *UpdatePostRequest, opts ...grpc.CallOption) (*Post, error)
 	DeletePost(ctx context.Context, in *DeletePostRequest, opts ...grpc.CallOption) (*DeletePostResponse, error)
 	ListPosts(ctx context.Context, in *ListPostsRequest, opts ...grpc.CallOption) (*ListPostsResponse, error)
+	EraseUserPosts(ctx context.Context, in *EraseUserPostsRequest, opts ...grpc.CallOption) (*EraseUserPostsResponse, error)
 }
 
 type postServiceClient struct {
@@ -95,6 +97,16 @@ func (c *postServiceClient) ListPosts(ctx context.Context, in *ListPostsRequest,
 	return out, nil
 }
 
+func (c *postServiceClient) EraseUserPosts(ctx context.Context, in *EraseUserPostsRequest, opts ...grpc.CallOption) (*EraseUserPostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EraseUserPostsResponse)
+	err := c.cc.Invoke(ctx, PostService_EraseUserPosts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PostServiceServer is the server API for PostService service.
 // All implementations must embed UnimplementedPostServiceServer
 // for forward compatibility.
@@ -104,6 +116,7 @@ type PostServiceServer interface {
 	UpdatePost(context.Context, *UpdatePostRequest) (*Post, error)
 	DeletePost(context.Context, *DeletePostRequest) (*DeletePostResponse, error)
 	ListPosts(context.Context, *ListPostsRequest) (*ListPostsResponse, error)
+	EraseUserPosts(context.Context, *EraseUserPostsRequest) (*EraseUserPostsResponse, error)
 	mustEmbedUnimplementedPostServiceServer()
 }
 
@@ -129,6 +142,9 @@ func (UnimplementedPostServiceServer) DeletePost(context.Context, *DeletePostReq
 func (UnimplementedPostServiceServer) ListPosts(context.Context, *ListPostsRequest) (*ListPostsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListPosts not implemented")
 }
+func (UnimplementedPostServiceServer) EraseUserPosts(context.Context, *EraseUserPostsRequest) (*EraseUserPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUserPosts not implemented")
+}
 func (UnimplementedPostServiceServer) mustEmbedUnimplementedPostServiceServer() {}
 func (UnimplementedPostServiceServer) testEmbeddedByValue()                     {}
 
@@ -240,6 +256,24 @@ func _PostService_ListPosts_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PostService_EraseUserPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseUserPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).EraseUserPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_EraseUserPosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).EraseUserPosts(ctx, req.(*EraseUserPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PostService_ServiceDesc is the grpc.ServiceDesc for PostService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +301,10 @@ var PostService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPosts",
 			Handler:    _PostService_ListPosts_Handler,
 		},
+		{
+			MethodName: "EraseUserPosts",
+			Handler:    _PostService_EraseUserPosts_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "post.proto",
@@ -0,0 +1,156 @@
+package relations
+
+import (
+	"context"
+	"social-network/common/proto"
+	"sync"
+	"time"
+)
+
+// Relations of one user, ids are user ids. Banned users are the same for everyone except themselves,
+// so the map is shared by all relations and must not be modified.
+type Relations struct {
+	UserID    uint64
+	Blocked   map[uint64]bool
+	BlockedBy map[uint64]bool
+	Muted     map[uint64]bool
+	Banned    map[uint64]bool
+}
+
+func fromProto(userID uint64, response *proto.UserRelations) *Relations {
+	relations := &Relations{
+		UserID:    userID,
+		Blocked:   make(map[uint64]bool, len(response.BlockedIds)),
+		BlockedBy: make(map[uint64]bool, len(response.BlockedByIds)),
+		Muted:     make(map[uint64]bool, len(response.MutedIds)),
+	}
+	for _, id := range response.BlockedIds {
+		relations.Blocked[id] = true
+	}
+	for _, id := range response.BlockedByIds {
+		relations.BlockedBy[id] = true
+	}
+	for _, id := range response.MutedIds {
+		relations.Muted[id] = true
+	}
+	return relations
+}
+
+// CanView reports whether the user may open content of the author,
+// which is not the case if the author blocked them or is banned. Banned users still see their own content.
+func (r *Relations) CanView(authorID uint64) bool {
+	return !r.BlockedBy[authorID] && (authorID == r.UserID || !r.Banned[authorID])
+}
+
+// HiddenAuthors returns authors whose content is left out of the user's listings:
+// blocked in either direction or muted by the user. Banned authors are not included,
+// listings filter them by the banned set shared by all users, which can grow large.
+func (r *Relations) HiddenAuthors() []uint64 {
+	var authors []uint64
+	seen := make(map[uint64]bool)
+	for _, ids := range []map[uint64]bool{r.Blocked, r.BlockedBy, r.Muted} {
+		for id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				authors = append(authors, id)
+			}
+		}
+	}
+	return authors
+}
+
+type cacheEntry struct {
+	relations *Relations
+	expiresAt time.Time
+}
+
+// CachedClient fetches relations from user-service and keeps them for cacheTTL,
+// so listings don't make a call to user-service on every request.
+// Banned users are fetched once for everyone, not with the relations of every user.
+type CachedClient struct {
+	client   proto.UserRelationServiceClient
+	cacheTTL time.Duration
+
+	mu              sync.Mutex
+	cache           map[uint64]cacheEntry
+	banned          map[uint64]bool
+	bannedExpiresAt time.Time
+}
+
+func NewCachedClient(client proto.UserRelationServiceClient, cacheTTL time.Duration) *CachedClient {
+	return &CachedClient{
+		client:   client,
+		cacheTTL: cacheTTL,
+		cache:    make(map[uint64]cacheEntry),
+	}
+}
+
+func (c *CachedClient) GetRelations(ctx context.Context, userID uint64) (*Relations, error) {
+	relations, err := c.userRelations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	banned, err := c.BannedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	withBanned := *relations
+	withBanned.Banned = banned
+	return &withBanned, nil
+}
+
+// BannedUsers returns the set of banned users, it is shared and must not be modified.
+func (c *CachedClient) BannedUsers(ctx context.Context) (map[uint64]bool, error) {
+	c.mu.Lock()
+	banned, expiresAt := c.banned, c.bannedExpiresAt
+	c.mu.Unlock()
+	if banned != nil && time.Now().Before(expiresAt) {
+		return banned, nil
+	}
+
+	response, err := c.client.ListBannedUsers(ctx, &proto.ListBannedUsersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	banned = make(map[uint64]bool, len(response.UserIds))
+	for _, id := range response.UserIds {
+		banned[id] = true
+	}
+	if c.cacheTTL > 0 {
+		c.mu.Lock()
+		c.banned, c.bannedExpiresAt = banned, time.Now().Add(c.cacheTTL)
+		c.mu.Unlock()
+	}
+	return banned, nil
+}
+
+func (c *CachedClient) userRelations(ctx context.Context, userID uint64) (*Relations, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[userID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.relations, nil
+	}
+
+	response, err := c.client.GetRelations(ctx, &proto.GetRelationsRequest{UserId: userID})
+	if err != nil {
+		return nil, err
+	}
+	relations := fromProto(userID, response)
+	if c.cacheTTL <= 0 {
+		return relations, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.cache) >= 10000 {
+		for key, entry := range c.cache {
+			if now.After(entry.expiresAt) {
+				delete(c.cache, key)
+			}
+		}
+	}
+	c.cache[userID] = cacheEntry{relations: relations, expiresAt: now.Add(c.cacheTTL)}
+	return relations, nil
+}
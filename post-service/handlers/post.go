@@ -6,18 +6,39 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"social-network/common/proto"
+	"social-network/common/relations"
 	"social-network/post-service/models"
 	"social-network/post-service/repositories"
+	"strconv"
 	"time"
 )
 
+// RelationProvider returns blocks and mutes of a user, which are stored in user-service.
+type RelationProvider interface {
+	GetRelations(ctx context.Context, userID uint64) (*relations.Relations, error)
+}
+
 type PostHandler struct {
-	repo *repositories.PostRepository
+	repo      *repositories.PostRepository
+	relations RelationProvider
 	proto.UnimplementedPostServiceServer
 }
 
-func NewPostHandler(repo *repositories.PostRepository) *PostHandler {
-	return &PostHandler{repo: repo}
+func NewPostHandler(repo *repositories.PostRepository, relations RelationProvider) *PostHandler {
+	return &PostHandler{repo: repo, relations: relations}
+}
+
+// requesterRelations returns nil for requesters which are not user ids, they have no relations.
+func (h *PostHandler) requesterRelations(ctx context.Context, requesterID string) (*relations.Relations, error) {
+	userID, err := strconv.ParseUint(requesterID, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	userRelations, err := h.relations.GetRelations(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to get user relations: %v", err)
+	}
+	return userRelations, nil
 }
 
 func convertPostToProto(post *models.Post) *proto.Post {
@@ -69,6 +90,15 @@ func (h *PostHandler) GetPost(ctx context.Context, req *proto.GetPostRequest) (*
 	if post == nil {
 		return nil, status.Errorf(codes.NotFound, "Post not found")
 	}
+	requesterRelations, err := h.requesterRelations(ctx, req.RequesterId)
+	if err != nil {
+		return nil, err
+	}
+	// checked before the privacy of the post, so the requester must not learn that the post exists
+	if creatorID, err := strconv.ParseUint(post.CreatorID, 10, 64); err == nil &&
+		requesterRelations != nil && !requesterRelations.CanView(creatorID) {
+		return nil, status.Errorf(codes.NotFound, "Post not found")
+	}
 	if post.IsPrivate && post.CreatorID != req.RequesterId {
 		return nil, status.Errorf(codes.PermissionDenied, "You don't have permission to view this post")
 	}
@@ -132,7 +162,18 @@ func (h *PostHandler) ListPosts(ctx context.Context, req *proto.ListPostsRequest
 		return nil, status.Errorf(codes.InvalidArgument, "Page size must be greater than 0")
 	}
 	includePrivate := req.RequesterId == req.CreatorId && req.CreatorId != ""
-	posts, totalCount, err := h.repo.ListPosts(page, pageSize, req.CreatorId, req.Tags, includePrivate, req.RequesterId)
+	requesterRelations, err := h.requesterRelations(ctx, req.RequesterId)
+	if err != nil {
+		return nil, err
+	}
+	var hiddenCreatorIDs []string
+	if requesterRelations != nil {
+		for _, authorID := range requesterRelations.HiddenAuthors() {
+			hiddenCreatorIDs = append(hiddenCreatorIDs, strconv.FormatUint(authorID, 10))
+		}
+	}
+	posts, totalCount, err := h.repo.ListPosts(
+		page, pageSize, req.CreatorId, req.Tags, includePrivate, req.RequesterId, hiddenCreatorIDs)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to list posts: %v", err)
 	}
@@ -147,3 +188,21 @@ func (h *PostHandler) ListPosts(ctx context.Context, req *proto.ListPostsRequest
 		TotalPages: totalPages,
 	}, nil
 }
+
+// EraseUserPosts is called by user-service when an account is erased.
+func (h *PostHandler) EraseUserPosts(ctx context.Context, req *proto.EraseUserPostsRequest) (*proto.EraseUserPostsResponse, error) {
+	if req.CreatorId == "" || req.CreatorId == models.DeletedCreatorID {
+		return nil, status.Errorf(codes.InvalidArgument, "Post creatorId is required")
+	}
+	var affected int64
+	var err error
+	if req.Anonymize {
+		affected, err = h.repo.AnonymizeCreatorPosts(req.CreatorId)
+	} else {
+		affected, err = h.repo.DeleteCreatorPosts(req.CreatorId)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to erase posts: %v", err)
+	}
+	return &proto.EraseUserPostsResponse{AffectedCount: affected}, nil
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"post-service/repositories"
+	"social-network/post-service/repositories"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,13 +12,24 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
-	"post-service/models"
-	"post-service/proto"
+	"social-network/common/proto"
+	"social-network/common/relations"
+	"social-network/post-service/models"
 )
 
+// fakeRelations maps user ids to their relations, other users have none.
+type fakeRelations map[uint64]*relations.Relations
+
+func (f fakeRelations) GetRelations(ctx context.Context, userID uint64) (*relations.Relations, error) {
+	if userRelations, ok := f[userID]; ok {
+		return userRelations, nil
+	}
+	return &relations.Relations{}, nil
+}
+
 func fixtureDb(t *testing.T) *repositories.PostRepository {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.PostTag{})
+	err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.PostTag{}, &models.BannedAuthor{})
 	assert.NoError(t, err)
 	return repositories.NewPostRepository(db)
 }
@@ -27,7 +38,7 @@ func TestCreatePost(t *testing.T) {
 	creatorID := "user123"
 
 	t.Run("successful creation", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		req := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -46,7 +57,7 @@ func TestCreatePost(t *testing.T) {
 	})
 
 	t.Run("missing title", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		req := &proto.CreatePostRequest{
 			Title:       "",
 			Description: "Test Description",
@@ -61,7 +72,7 @@ func TestCreatePost(t *testing.T) {
 	})
 
 	t.Run("missing creator ID", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		req := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -81,7 +92,7 @@ func TestGetPost(t *testing.T) {
 	requesterID := "user123"
 
 	t.Run("successful get", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -117,7 +128,7 @@ func TestGetPost(t *testing.T) {
 	})
 
 	t.Run("post not found", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		postID := 1
 		req := &proto.GetPostRequest{
 			Id:          uint64(postID),
@@ -132,7 +143,7 @@ func TestGetPost(t *testing.T) {
 	})
 
 	t.Run("no access to private post", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		otherRequesterID := "user456"
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
@@ -161,7 +172,7 @@ func TestGetPost(t *testing.T) {
 func TestUpdatePost(t *testing.T) {
 	creatorID := "user123"
 	t.Run("successful update", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -192,7 +203,7 @@ func TestUpdatePost(t *testing.T) {
 	})
 
 	t.Run("post not found", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -218,7 +229,7 @@ func TestUpdatePost(t *testing.T) {
 	})
 
 	t.Run("not post owner", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -250,7 +261,7 @@ func TestDeletePost(t *testing.T) {
 	creatorID := "user123"
 
 	t.Run("successful delete", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -275,7 +286,7 @@ func TestDeletePost(t *testing.T) {
 	})
 
 	t.Run("post not found", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
 			Description: "Test Description",
@@ -302,7 +313,7 @@ func TestDeletePost(t *testing.T) {
 	})
 
 	t.Run("not post owner", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		otherUserID := "user456"
 		createReq := &proto.CreatePostRequest{
 			Title:       "Test Post",
@@ -333,7 +344,7 @@ func TestListPosts(t *testing.T) {
 	creatorID := "user456"
 
 	t.Run("successful list", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		posts := []models.Post{
 			{
 				Title:       "Post 1",
@@ -384,7 +395,7 @@ func TestListPosts(t *testing.T) {
 	})
 
 	t.Run("empty list", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		req := &proto.ListPostsRequest{
 			Page:        1,
 			PageSize:    10,
@@ -401,7 +412,7 @@ func TestListPosts(t *testing.T) {
 	})
 
 	t.Run("empty list [by tag]", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		posts := []models.Post{
 			{
 				Title:       "Post 1",
@@ -452,7 +463,7 @@ func TestListPosts(t *testing.T) {
 	})
 
 	t.Run("no private", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		posts := []models.Post{
 			{
 				Title:       "Post 1",
@@ -503,7 +514,7 @@ func TestListPosts(t *testing.T) {
 	})
 
 	t.Run("pagination", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		posts := []models.Post{
 			{
 				Title:       "Post 1",
@@ -549,7 +560,7 @@ func TestListPosts(t *testing.T) {
 	})
 
 	t.Run("bad pagination", func(t *testing.T) {
-		handler := NewPostHandler(fixtureDb(t))
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
 		req := &proto.ListPostsRequest{
 			Page:        -2,
 			PageSize:    10,
@@ -568,3 +579,192 @@ func TestListPosts(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestBlockedAndMutedAuthors(t *testing.T) {
+	userRelations := fakeRelations{
+		1: {
+			BlockedBy: map[uint64]bool{2: true},
+			Muted:     map[uint64]bool{3: true},
+		},
+	}
+	handler := NewPostHandler(fixtureDb(t), userRelations)
+	postIDs := make(map[string]uint64)
+	for _, creatorID := range []string{"2", "3", "4"} {
+		post, err := handler.CreatePost(context.Background(), &proto.CreatePostRequest{
+			Title:     "Post by " + creatorID,
+			CreatorId: creatorID,
+		})
+		require.NoError(t, err)
+		postIDs[creatorID] = post.Id
+	}
+
+	t.Run("blocked requester can't get the blocker's post", func(t *testing.T) {
+		_, err := handler.GetPost(context.Background(), &proto.GetPostRequest{Id: postIDs["2"], RequesterId: "1"})
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.NotFound, st.Code())
+
+		post, err := handler.GetPost(context.Background(), &proto.GetPostRequest{Id: postIDs["2"], RequesterId: "4"})
+		require.NoError(t, err)
+		assert.Equal(t, "2", post.CreatorId)
+	})
+
+	t.Run("blocked requester can't tell that a private post exists", func(t *testing.T) {
+		post, err := handler.CreatePost(context.Background(), &proto.CreatePostRequest{
+			Title:     "Private post by 2",
+			CreatorId: "2",
+			IsPrivate: true,
+		})
+		require.NoError(t, err)
+		_, err = handler.GetPost(context.Background(), &proto.GetPostRequest{Id: post.Id, RequesterId: "1"})
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.NotFound, st.Code())
+
+		_, err = handler.GetPost(context.Background(), &proto.GetPostRequest{Id: post.Id, RequesterId: "4"})
+		st, ok = status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.PermissionDenied, st.Code())
+	})
+
+	t.Run("muted posts can still be opened directly", func(t *testing.T) {
+		_, err := handler.GetPost(context.Background(), &proto.GetPostRequest{Id: postIDs["3"], RequesterId: "1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("listing hides blocked and muted authors", func(t *testing.T) {
+		response, err := handler.ListPosts(context.Background(), &proto.ListPostsRequest{
+			Page:        1,
+			PageSize:    10,
+			RequesterId: "1",
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Posts, 1)
+		assert.Equal(t, "4", response.Posts[0].CreatorId)
+		assert.Equal(t, int32(1), response.TotalCount)
+
+		response, err = handler.ListPosts(context.Background(), &proto.ListPostsRequest{
+			Page:        1,
+			PageSize:    10,
+			RequesterId: "1",
+			CreatorId:   "2",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, response.Posts)
+	})
+}
+
+func TestBannedAuthors(t *testing.T) {
+	repo := fixtureDb(t)
+	banned := map[uint64]bool{2: true}
+	userRelations := fakeRelations{
+		1: {UserID: 1, Banned: banned},
+		2: {UserID: 2, Banned: banned},
+	}
+	handler := NewPostHandler(repo, userRelations)
+	postIDs := make(map[string]uint64)
+	for _, creatorID := range []string{"2", "3"} {
+		post, err := handler.CreatePost(context.Background(), &proto.CreatePostRequest{
+			Title:     "Post by " + creatorID,
+			CreatorId: creatorID,
+			Tags:      []string{"news"},
+		})
+		require.NoError(t, err)
+		postIDs[creatorID] = post.Id
+	}
+	require.NoError(t, repo.ReplaceBannedAuthors([]string{"2"}))
+
+	listCreators := func(requesterID string, tags []string) []string {
+		response, err := handler.ListPosts(context.Background(), &proto.ListPostsRequest{
+			Page:        1,
+			PageSize:    10,
+			RequesterId: requesterID,
+			Tags:        tags,
+		})
+		require.NoError(t, err)
+		var creators []string
+		for _, post := range response.Posts {
+			creators = append(creators, post.CreatorId)
+		}
+		return creators
+	}
+
+	t.Run("listings hide banned authors", func(t *testing.T) {
+		assert.Equal(t, []string{"3"}, listCreators("1", nil))
+		assert.Equal(t, []string{"3"}, listCreators("1", []string{"news"}))
+		assert.Equal(t, []string{"3"}, listCreators("", nil))
+	})
+
+	t.Run("banned authors still see their own posts", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"2", "3"}, listCreators("2", nil))
+		_, err := handler.GetPost(context.Background(), &proto.GetPostRequest{Id: postIDs["2"], RequesterId: "2"})
+		require.NoError(t, err)
+	})
+
+	t.Run("banned author's post can't be opened", func(t *testing.T) {
+		_, err := handler.GetPost(context.Background(), &proto.GetPostRequest{Id: postIDs["2"], RequesterId: "1"})
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.NotFound, st.Code())
+	})
+
+	t.Run("reinstated authors are listed again", func(t *testing.T) {
+		require.NoError(t, repo.ReplaceBannedAuthors(nil))
+		assert.ElementsMatch(t, []string{"2", "3"}, listCreators("1", nil))
+	})
+}
+
+func TestEraseUserPosts(t *testing.T) {
+	createPosts := func(t *testing.T, handler *PostHandler) {
+		for _, creatorID := range []string{"1", "1", "2"} {
+			_, err := handler.CreatePost(context.Background(), &proto.CreatePostRequest{
+				Title:     "Post",
+				CreatorId: creatorID,
+				Tags:      []string{"tag"},
+			})
+			require.NoError(t, err)
+		}
+	}
+	listAll := func(t *testing.T, handler *PostHandler) []*proto.Post {
+		response, err := handler.ListPosts(context.Background(), &proto.ListPostsRequest{Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		return response.Posts
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
+		createPosts(t, handler)
+		response, err := handler.EraseUserPosts(context.Background(), &proto.EraseUserPostsRequest{CreatorId: "1"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), response.AffectedCount)
+
+		posts := listAll(t, handler)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "2", posts[0].CreatorId)
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
+		createPosts(t, handler)
+		response, err := handler.EraseUserPosts(context.Background(), &proto.EraseUserPostsRequest{
+			CreatorId: "1",
+			Anonymize: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), response.AffectedCount)
+
+		creators := make([]string, 0)
+		for _, post := range listAll(t, handler) {
+			creators = append(creators, post.CreatorId)
+		}
+		assert.ElementsMatch(t, []string{models.DeletedCreatorID, models.DeletedCreatorID, "2"}, creators)
+	})
+
+	t.Run("missing creator", func(t *testing.T) {
+		handler := NewPostHandler(fixtureDb(t), fakeRelations{})
+		_, err := handler.EraseUserPosts(context.Background(), &proto.EraseUserPostsRequest{})
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+	})
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"social-network/common/proto"
+	"social-network/common/relations"
 	"social-network/post-service/handlers"
 	"social-network/post-service/models"
 	"social-network/post-service/repositories"
+	"strconv"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -27,12 +32,30 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	if err = db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.PostTag{}); err != nil {
+	if err = db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.PostTag{}, &models.BannedAuthor{}); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	userServiceURL := os.Getenv("USER_SERVICE_GRPC_URL")
+	if userServiceURL == "" {
+		userServiceURL = "user-service:50052"
+	}
+	userConn, err := grpc.NewClient(userServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to user service: %v", err)
+	}
+	defer userConn.Close()
+	relationsCacheTTL := 10 * time.Second
+	if value := os.Getenv("RELATIONS_CACHE_TTL"); value != "" {
+		if relationsCacheTTL, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid RELATIONS_CACHE_TTL: %v", err)
+		}
+	}
+	userRelations := relations.NewCachedClient(proto.NewUserRelationServiceClient(userConn), relationsCacheTTL)
+
 	repo := repositories.NewPostRepository(db)
-	handler := handlers.NewPostHandler(repo)
+	go syncBannedAuthors(repo, userRelations, relationsCacheTTL)
+	handler := handlers.NewPostHandler(repo, userRelations)
 	port := os.Getenv("GRPC_PORT")
 	if port == "" {
 		port = "50051"
@@ -49,3 +72,26 @@ func main() {
 		log.Fatalf("Failed to serve: %v", err)
 	}
 }
+
+// syncBannedAuthors copies the banned users from user-service into the posts database every interval.
+func syncBannedAuthors(repo *repositories.PostRepository, userRelations *relations.CachedClient, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		banned, err := userRelations.BannedUsers(ctx)
+		cancel()
+		if err == nil {
+			creatorIDs := make([]string, 0, len(banned))
+			for id := range banned {
+				creatorIDs = append(creatorIDs, strconv.FormatUint(id, 10))
+			}
+			err = repo.ReplaceBannedAuthors(creatorIDs)
+		}
+		if err != nil {
+			log.Printf("Failed to sync banned authors: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
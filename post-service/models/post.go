@@ -2,6 +2,9 @@ package models
 
 import "gorm.io/gorm"
 
+// DeletedCreatorID replaces the creator of posts kept after their creator deleted the account.
+const DeletedCreatorID = "deleted"
+
 type Post struct {
 	gorm.Model
 	Title       string `json:"title" gorm:"not null"`
@@ -17,6 +20,12 @@ type Tag struct {
 	Posts []Post `gorm:"many2many:post_tags;"`
 }
 
+// BannedAuthor is a user banned in user-service. The set is copied into this database,
+// so listings filter banned authors with a join instead of passing all their ids.
+type BannedAuthor struct {
+	CreatorID string `gorm:"primaryKey"`
+}
+
 type PostTag struct {
 	PostID uint `gorm:"not null"`
 	TagID  uint `gorm:"not null"`
@@ -104,7 +104,8 @@ func (r *PostRepository) ListPosts(
 	creatorID string,
 	tagNames []string,
 	includePrivate bool,
-	requesterID string) ([]models.Post, int64, error) {
+	requesterID string,
+	hiddenCreatorIDs []string) ([]models.Post, int64, error) {
 	query := r.db.Model(&models.Post{})
 	var count int64
 	if creatorID != "" {
@@ -113,6 +114,12 @@ func (r *PostRepository) ListPosts(
 	if !includePrivate {
 		query = query.Where("is_private = ? OR creator_id = ?", false, requesterID)
 	}
+	if len(hiddenCreatorIDs) > 0 {
+		query = query.Where("creator_id NOT IN ?", hiddenCreatorIDs)
+	}
+	// banned users still see their own posts
+	query = query.Where(`NOT EXISTS (SELECT 1 FROM banned_authors
+		WHERE banned_authors.creator_id = posts.creator_id AND banned_authors.creator_id <> ?)`, requesterID)
 	if len(tagNames) > 0 {
 		query = query.Joins("JOIN post_tags ON post_tags.post_id = posts.id").
 			Joins("JOIN tags ON tags.id = post_tags.tag_id").
@@ -128,3 +135,43 @@ func (r *PostRepository) ListPosts(
 	}
 	return posts, count, nil
 }
+
+// ReplaceBannedAuthors makes creatorIDs the only banned authors.
+func (r *PostRepository) ReplaceBannedAuthors(creatorIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.BannedAuthor{}).Error; err != nil {
+			return err
+		}
+		if len(creatorIDs) == 0 {
+			return nil
+		}
+		authors := make([]models.BannedAuthor, len(creatorIDs))
+		for i, creatorID := range creatorIDs {
+			authors[i].CreatorID = creatorID
+		}
+		return tx.CreateInBatches(authors, 500).Error
+	})
+}
+
+// DeleteCreatorPosts permanently deletes all posts of the creator, including soft deleted ones.
+func (r *PostRepository) DeleteCreatorPosts(creatorID string) (int64, error) {
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		postIDs := tx.Unscoped().Model(&models.Post{}).Select("id").Where("creator_id = ?", creatorID)
+		if err := tx.Where("post_id IN (?)", postIDs).Delete(&models.PostTag{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("creator_id = ?", creatorID).Delete(&models.Post{})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	return affected, err
+}
+
+// AnonymizeCreatorPosts keeps the posts but removes the link to their creator.
+func (r *PostRepository) AnonymizeCreatorPosts(creatorID string) (int64, error) {
+	result := r.db.Unscoped().Model(&models.Post{}).
+		Where("creator_id = ?", creatorID).
+		Update("creator_id", models.DeletedCreatorID)
+	return result.RowsAffected, result.Error
+}
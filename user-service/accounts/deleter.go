@@ -0,0 +1,149 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"social-network/common/proto"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+var (
+	ErrDeletionAlreadyScheduled = errors.New("account deletion is already scheduled")
+	ErrNoPendingDeletion        = errors.New("account deletion is not scheduled")
+)
+
+// PostEraser is the part of the post-service API used to erase posts of deleted accounts.
+type PostEraser interface {
+	EraseUserPosts(ctx context.Context, in *proto.EraseUserPostsRequest, opts ...grpc.CallOption) (*proto.EraseUserPostsResponse, error)
+}
+
+// AvatarEraser removes the stored avatars of deleted accounts.
+type AvatarEraser interface {
+	DeleteAll(ctx context.Context, userID uint) error
+}
+
+// Deleter schedules account deletions and erases accounts after the grace period,
+// until then the user can cancel the deletion.
+type Deleter struct {
+	repo           *repositories.AccountDeletionRepository
+	posts          PostEraser
+	avatars        AvatarEraser
+	gracePeriod    time.Duration
+	anonymizePosts bool
+	Now            func() time.Time
+}
+
+func NewDeleter(
+	repo *repositories.AccountDeletionRepository,
+	posts PostEraser,
+	avatars AvatarEraser,
+	gracePeriod time.Duration,
+	anonymizePosts bool) *Deleter {
+	return &Deleter{
+		repo:           repo,
+		posts:          posts,
+		avatars:        avatars,
+		gracePeriod:    gracePeriod,
+		anonymizePosts: anonymizePosts,
+		Now:            time.Now,
+	}
+}
+
+func (d *Deleter) Schedule(user *models.User) (*models.AccountDeletion, error) {
+	pending, err := d.repo.FindPending(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return pending, ErrDeletionAlreadyScheduled
+	}
+	now := d.Now()
+	deletion := &models.AccountDeletion{
+		UserID:       user.ID,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(d.gracePeriod),
+	}
+	if err = d.repo.Create(deletion); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+func (d *Deleter) Cancel(user *models.User) error {
+	pending, err := d.repo.FindPending(user.ID)
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		return ErrNoPendingDeletion
+	}
+	return d.repo.Cancel(pending, d.Now())
+}
+
+// Pending returns the scheduled deletion of the user or nil.
+func (d *Deleter) Pending(user *models.User) (*models.AccountDeletion, error) {
+	return d.repo.FindPending(user.ID)
+}
+
+// ProcessDue erases accounts whose grace period is over. Posts and avatars are erased first, so if post-service
+// is unavailable the account stays and the deletion is retried on the next run.
+func (d *Deleter) ProcessDue(ctx context.Context) (int, error) {
+	deletions, err := d.repo.ListDue(d.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+	completed := 0
+	for i := range deletions {
+		if err = d.erase(ctx, &deletions[i]); err != nil {
+			log.Printf("Failed to erase account %d: %v", deletions[i].UserID, err)
+			if recordErr := d.repo.RecordFailure(&deletions[i], err.Error()); recordErr != nil {
+				return completed, recordErr
+			}
+			continue
+		}
+		completed++
+	}
+	return completed, nil
+}
+
+func (d *Deleter) erase(ctx context.Context, deletion *models.AccountDeletion) error {
+	response, err := d.posts.EraseUserPosts(ctx, &proto.EraseUserPostsRequest{
+		CreatorId: strconv.FormatUint(uint64(deletion.UserID), 10),
+		Anonymize: d.anonymizePosts,
+	})
+	if err != nil {
+		return fmt.Errorf("erase posts: %w", err)
+	}
+	deletion.PostsErased += response.AffectedCount
+	deletion.PostsAnonymized = d.anonymizePosts
+	if err = d.avatars.DeleteAll(ctx, deletion.UserID); err != nil {
+		return fmt.Errorf("erase avatars: %w", err)
+	}
+	return d.repo.EraseUser(deletion, d.Now())
+}
+
+// Run calls ProcessDue every interval until the context is done.
+func (d *Deleter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		completed, err := d.ProcessDue(ctx)
+		if err != nil {
+			log.Printf("Error during account deletion: %v", err)
+		} else if completed > 0 {
+			log.Printf("Erased %d accounts", completed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package accounts
+
+import (
+	"errors"
+	"social-network/user-service/auth"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"time"
+)
+
+// InviteCodePrefix starts every invite code, so they can't be confused with other tokens.
+const InviteCodePrefix = "sninv_"
+
+// UserInviteMaxUses limits the codes of regular users, admins can invite any number of people at once.
+const UserInviteMaxUses = 10
+
+var (
+	ErrInviteRequired    = errors.New("registration requires an invite code")
+	ErrInvalidInvite     = errors.New("invite code is unknown, used up, expired or revoked")
+	ErrInviteLimitTooBig = errors.New("invite code allows too many registrations")
+)
+
+// Inviter issues and redeems invite codes. With InviteOnly set registration requires a code,
+// otherwise a code is optional and only records who invited the new user.
+type Inviter struct {
+	repo       *repositories.InviteRepository
+	InviteOnly bool
+	Now        func() time.Time
+}
+
+func NewInviter(repo *repositories.InviteRepository, inviteOnly bool) *Inviter {
+	return &Inviter{repo: repo, InviteOnly: inviteOnly, Now: time.Now}
+}
+
+// Create returns the code itself, it can't be shown again since only its hash is stored.
+func (i *Inviter) Create(creator *models.User, maxUses int, expiresAt *time.Time) (string, *models.InviteCode, error) {
+	if maxUses > UserInviteMaxUses && !creator.HasRole(models.RoleAdmin) {
+		return "", nil, ErrInviteLimitTooBig
+	}
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	code := InviteCodePrefix + secret
+	invite := &models.InviteCode{
+		CreatedByID: creator.ID,
+		Prefix:      code[:len(InviteCodePrefix)+4],
+		CodeHash:    auth.HashToken(code),
+		MaxUses:     maxUses,
+		ExpiresAt:   expiresAt,
+	}
+	if err = i.repo.Create(invite); err != nil {
+		return "", nil, err
+	}
+	return code, invite, nil
+}
+
+// Redeem takes one use of the code for a new registration. Without a code it returns nil,
+// unless registration is invite-only.
+func (i *Inviter) Redeem(code string) (*models.InviteCode, error) {
+	if code == "" {
+		if i.InviteOnly {
+			return nil, ErrInviteRequired
+		}
+		return nil, nil
+	}
+	invite, err := i.repo.FindByHash(auth.HashToken(code))
+	if err != nil {
+		return nil, err
+	}
+	now := i.Now()
+	if invite == nil || !invite.IsUsable(now) {
+		return nil, ErrInvalidInvite
+	}
+	redeemed, err := i.repo.Redeem(invite.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, ErrInvalidInvite
+	}
+	invite.UsedCount++
+	return invite, nil
+}
+
+// Release gives the use back if the registration failed after Redeem.
+func (i *Inviter) Release(invite *models.InviteCode) error {
+	return i.repo.Release(invite.ID)
+}
+
+// List returns the codes of the user together with the users who registered with them.
+func (i *Inviter) List(creatorID uint) ([]models.InviteCode, []models.User, error) {
+	invites, err := i.repo.ListByCreator(creatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uint, len(invites))
+	for j := range invites {
+		ids[j] = invites[j].ID
+	}
+	invitees, err := i.repo.ListInvitees(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	return invites, invitees, nil
+}
+
+// Revoke returns false if the user has no such active code, people already invited keep their accounts.
+func (i *Inviter) Revoke(creatorID, inviteID uint) (bool, error) {
+	return i.repo.Revoke(creatorID, inviteID, i.Now())
+}
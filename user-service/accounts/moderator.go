@@ -0,0 +1,97 @@
+package accounts
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"time"
+)
+
+var (
+	ErrSelfModeration       = errors.New("admins can't moderate themselves")
+	ErrInvalidSuspensionEnd = errors.New("suspension must end in the future")
+	ErrNotRestricted        = errors.New("user is neither suspended nor banned")
+)
+
+// SessionRevoker ends the sessions of a restricted user, so their refresh tokens stop working at once.
+type SessionRevoker interface {
+	RevokeAllSessions(userID uint) error
+}
+
+// Moderator suspends, bans and reinstates users. Every action is recorded with the acting admin.
+type Moderator struct {
+	repo     *repositories.ModerationRepository
+	sessions SessionRevoker
+	Now      func() time.Time
+}
+
+func NewModerator(repo *repositories.ModerationRepository, sessions SessionRevoker) *Moderator {
+	return &Moderator{repo: repo, sessions: sessions, Now: time.Now}
+}
+
+// Suspend blocks the user until the given time, a later suspension replaces the current one.
+func (m *Moderator) Suspend(user *models.User, adminID uint, reason string, until time.Time) (*models.ModerationAction, error) {
+	if user.ID == adminID {
+		return nil, ErrSelfModeration
+	}
+	if !until.After(m.Now()) {
+		return nil, ErrInvalidSuspensionEnd
+	}
+	user.SuspendedUntil = &until
+	user.ModerationReason = reason
+	return m.restrict(user, &models.ModerationAction{
+		UserID:         user.ID,
+		AdminID:        adminID,
+		Action:         models.ModerationSuspend,
+		Reason:         reason,
+		SuspendedUntil: &until,
+	})
+}
+
+// Ban blocks the user until they are reinstated and hides their posts from everyone.
+func (m *Moderator) Ban(user *models.User, adminID uint, reason string) (*models.ModerationAction, error) {
+	if user.ID == adminID {
+		return nil, ErrSelfModeration
+	}
+	now := m.Now()
+	if user.BannedAt == nil {
+		user.BannedAt = &now
+	}
+	user.ModerationReason = reason
+	return m.restrict(user, &models.ModerationAction{
+		UserID:  user.ID,
+		AdminID: adminID,
+		Action:  models.ModerationBan,
+		Reason:  reason,
+	})
+}
+
+// Reinstate lifts the ban and the suspension, the user has to log in again.
+func (m *Moderator) Reinstate(user *models.User, adminID uint, reason string) (*models.ModerationAction, error) {
+	if !user.IsRestricted(m.Now()) {
+		return nil, ErrNotRestricted
+	}
+	user.SuspendedUntil = nil
+	user.BannedAt = nil
+	user.ModerationReason = ""
+	action := &models.ModerationAction{
+		UserID:  user.ID,
+		AdminID: adminID,
+		Action:  models.ModerationReinstate,
+		Reason:  reason,
+	}
+	if err := m.repo.Apply(user, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func (m *Moderator) restrict(user *models.User, action *models.ModerationAction) (*models.ModerationAction, error) {
+	if err := m.repo.Apply(user, action); err != nil {
+		return nil, err
+	}
+	if err := m.sessions.RevokeAllSessions(user.ID); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
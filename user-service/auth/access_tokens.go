@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix starts every personal access token, so they can't be confused with JWTs and are easy to find
+// by secret scanners.
+const AccessTokenPrefix = "snpat_"
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+// AccessTokenManager issues personal access tokens. Unlike access tokens they aren't bound to a session,
+// live until they expire or are revoked and only allow what their scopes grant.
+type AccessTokenManager struct {
+	repo *repositories.AccessTokenRepository
+	Now  func() time.Time
+}
+
+func NewAccessTokenManager(repo *repositories.AccessTokenRepository) *AccessTokenManager {
+	return &AccessTokenManager{repo: repo, Now: time.Now}
+}
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// Create returns the token itself, it can't be shown again since only its hash is stored.
+func (m *AccessTokenManager) Create(
+	user *models.User, name string, scopes []string, expiresAt *time.Time) (string, *models.PersonalAccessToken, error) {
+	var granted []string
+	for _, scope := range scopes {
+		if !slices.Contains(models.AccessTokenScopes, scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	if len(granted) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+
+	secret, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := AccessTokenPrefix + secret
+	accessToken := &models.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    token[:len(AccessTokenPrefix)+4],
+		TokenHash: HashToken(token),
+		Scopes:    strings.Join(granted, " "),
+		ExpiresAt: expiresAt,
+	}
+	if err = m.repo.Create(accessToken); err != nil {
+		return "", nil, err
+	}
+	return token, accessToken, nil
+}
+
+// Authenticate returns the active token, ErrInvalidToken if it is unknown, revoked or expired.
+func (m *AccessTokenManager) Authenticate(token string) (*models.PersonalAccessToken, error) {
+	if !IsAccessToken(token) {
+		return nil, ErrInvalidToken
+	}
+	accessToken, err := m.repo.FindByHash(HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := m.Now()
+	if accessToken == nil || !accessToken.IsActive(now) {
+		return nil, ErrInvalidToken
+	}
+	// the gateway caches introspection results, so this is written at most once per cache period anyway
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) > time.Minute {
+		if err = m.repo.TouchLastUsed(accessToken.ID, now); err != nil {
+			return nil, err
+		}
+		accessToken.LastUsedAt = &now
+	}
+	return accessToken, nil
+}
+
+func (m *AccessTokenManager) List(user *models.User) ([]models.PersonalAccessToken, error) {
+	return m.repo.ListByUser(user.ID)
+}
+
+// Revoke returns false if the user has no such active token.
+func (m *AccessTokenManager) Revoke(user *models.User, tokenID uint) (bool, error) {
+	return m.repo.Revoke(user.ID, tokenID, m.Now())
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"social-network/user-service/models"
+	"social-network/user-service/notifier"
+	"social-network/user-service/repositories"
+	"time"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// EmailVerifier sends one-time tokens which prove that the user owns their email.
+type EmailVerifier struct {
+	repo     *repositories.EmailVerificationRepository
+	notifier notifier.Notifier
+	ttl      time.Duration
+}
+
+func NewEmailVerifier(
+	repo *repositories.EmailVerificationRepository,
+	notifier notifier.Notifier,
+	ttl time.Duration) *EmailVerifier {
+	return &EmailVerifier{
+		repo:     repo,
+		notifier: notifier,
+		ttl:      ttl,
+	}
+}
+
+func (v *EmailVerifier) SendVerification(user *models.User) error {
+	token, err := GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = v.repo.CreateToken(&models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(v.ttl),
+	})
+	if err != nil {
+		return err
+	}
+	return v.notifier.Notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Email verification",
+		Body: fmt.Sprintf("Use this token to verify the email of %s: %s\nThe token expires in %s.",
+			user.Username, token, v.ttl),
+		Data: map[string]string{"email_verification_token": token},
+	})
+}
+
+// Verify marks the email the token was sent to as verified and returns the user id.
+func (v *EmailVerifier) Verify(token string) (uint, error) {
+	verificationToken, err := v.repo.FindByHash(HashToken(token))
+	if err != nil {
+		return 0, err
+	}
+	if verificationToken == nil || verificationToken.UsedAt != nil || time.Now().After(verificationToken.ExpiresAt) {
+		return 0, ErrInvalidVerificationToken
+	}
+	verified, err := v.repo.MarkVerified(verificationToken)
+	if err != nil {
+		return 0, err
+	}
+	if !verified {
+		return 0, ErrInvalidVerificationToken
+	}
+	return verificationToken.UserID, nil
+}
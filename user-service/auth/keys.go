@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"social-network/common/jwks"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey is a private key used to sign access tokens, its kid is the thumbprint of the public key.
+type SigningKey struct {
+	ID      string
+	Private crypto.Signer
+	Method  jwt.SigningMethod
+	jwk     jwks.Key
+}
+
+func NewSigningKey(private crypto.Signer) (*SigningKey, error) {
+	jwk, err := jwks.NewKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	method := jwt.GetSigningMethod(jwk.Alg)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", jwk.Alg)
+	}
+	if rsaKey, ok := private.(*rsa.PrivateKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits long")
+	}
+	return &SigningKey{ID: jwk.Kid, Private: private, Method: method, jwk: jwk}, nil
+}
+
+// GenerateSigningKey creates a new Ed25519 key.
+func GenerateSigningKey() (*SigningKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningKey(private)
+}
+
+// LoadSigningKey reads a PEM encoded PKCS #8 RSA or Ed25519 private key, PKCS #1 RSA keys are accepted as well.
+func LoadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s doesn't contain a PEM encoded key", path)
+	}
+	var private interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %s in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T in %s", private, path)
+	}
+	return NewSigningKey(signer)
+}
+
+type retiredKey struct {
+	key *SigningKey
+	// until is zero for keys which stay valid until they are removed from the configuration
+	until time.Time
+}
+
+// KeyRing holds the key which signs new tokens and the previous keys which still verify tokens signed before
+// a rotation. A rotated out key is kept for the overlap period, which has to cover the access token lifetime.
+type KeyRing struct {
+	mu       sync.RWMutex
+	active   *SigningKey
+	previous []retiredKey
+	overlap  time.Duration
+	Now      func() time.Time
+}
+
+func NewKeyRing(active *SigningKey, overlap time.Duration, previous ...*SigningKey) *KeyRing {
+	ring := &KeyRing{active: active, overlap: overlap, Now: time.Now}
+	for _, key := range previous {
+		ring.previous = append(ring.previous, retiredKey{key: key})
+	}
+	return ring
+}
+
+func (r *KeyRing) Active() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// VerificationKey returns the key with the kid if tokens signed with it are still accepted.
+func (r *KeyRing) VerificationKey(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.active.ID == kid {
+		return r.active, true
+	}
+	now := r.Now()
+	for _, retired := range r.previous {
+		if retired.key.ID == kid && (retired.until.IsZero() || now.Before(retired.until)) {
+			return retired.key, true
+		}
+	}
+	return nil, false
+}
+
+// Rotate makes the key active, the current one only verifies tokens until the overlap period ends.
+func (r *KeyRing) Rotate(next *SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.Now()
+	previous := []retiredKey{{key: r.active, until: now.Add(r.overlap)}}
+	for _, retired := range r.previous {
+		if retired.until.IsZero() || now.Before(retired.until) {
+			previous = append(previous, retired)
+		}
+	}
+	r.active = next
+	r.previous = previous
+}
+
+// JWKS returns the public keys which are still accepted, the active one first.
+func (r *KeyRing) JWKS() jwks.Set {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	set := jwks.Set{Keys: []jwks.Key{r.active.jwk}}
+	now := r.Now()
+	for _, retired := range r.previous {
+		if retired.until.IsZero() || now.Before(retired.until) {
+			set.Keys = append(set.Keys, retired.key.jwk)
+		}
+	}
+	return set
+}
+
+// RunRotation rotates to a newly generated key every interval until the context is done.
+// Generated keys live in memory only, so it only suits a single user-service instance.
+func (r *KeyRing) RunRotation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			key, err := GenerateSigningKey()
+			if err != nil {
+				log.Printf("Failed to generate signing key: %v", err)
+				continue
+			}
+			r.Rotate(key)
+			log.Printf("Rotated signing key, new kid %s", key.ID)
+		}
+	}
+}
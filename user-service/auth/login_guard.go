@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"log"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"time"
+)
+
+type LoginGuardConfig struct {
+	// MaxAccountFailures failed logins within FailureWindow lock the account
+	MaxAccountFailures int
+	// LockoutDuration is the first lockout duration, every next lockout within a day is twice as long
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+	// MaxIPFailures failed logins from one ip within FailureWindow throttle the ip,
+	// no matter which accounts were tried
+	MaxIPFailures int
+	FailureWindow time.Duration
+}
+
+func DefaultLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		MaxAccountFailures: 5,
+		LockoutDuration:    5 * time.Minute,
+		MaxLockoutDuration: 24 * time.Hour,
+		MaxIPFailures:      50,
+		FailureWindow:      15 * time.Minute,
+	}
+}
+
+// LoginGuard protects Login from password guessing and credential stuffing.
+type LoginGuard struct {
+	repo   *repositories.LoginAttemptRepository
+	config LoginGuardConfig
+	Now    func() time.Time
+}
+
+func NewLoginGuard(repo *repositories.LoginAttemptRepository, config LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{
+		repo:   repo,
+		config: config,
+		Now:    time.Now,
+	}
+}
+
+// Check returns a positive duration if the login attempt must be rejected without checking the password.
+func (g *LoginGuard) Check(username, ip string) (time.Duration, error) {
+	now := g.Now()
+	lockout, err := g.repo.FindActiveLockout(username, now)
+	if err != nil {
+		return 0, err
+	}
+	if lockout != nil {
+		return lockout.LockedUntil.Sub(now), nil
+	}
+
+	oldestFailure, ipFailures, err := g.repo.OldestIPFailure(ip, now.Add(-g.config.FailureWindow))
+	if err != nil {
+		return 0, err
+	}
+	if ipFailures >= int64(g.config.MaxIPFailures) {
+		return oldestFailure.CreatedAt.Add(g.config.FailureWindow).Sub(now), nil
+	}
+	return 0, nil
+}
+
+// RecordFailure stores a failed attempt and locks the account if there were too many of them.
+// user is nil if the username doesn't exist, such usernames are locked as well,
+// so responses don't reveal which accounts exist.
+func (g *LoginGuard) RecordFailure(username string, user *models.User, ip, userAgent string) error {
+	now := g.Now()
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	err := g.repo.CreateFailure(&models.LoginFailure{
+		Username:  username,
+		UserID:    userID,
+		IP:        ip,
+		UserAgent: userAgent,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	failures, err := g.repo.CountUsernameFailures(username, now.Add(-g.config.FailureWindow))
+	if err != nil {
+		return err
+	}
+	if failures < int64(g.config.MaxAccountFailures) {
+		return nil
+	}
+
+	previousLockouts, err := g.repo.CountLockouts(username, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	duration := g.config.LockoutDuration
+	for i := int64(0); i < previousLockouts && duration < g.config.MaxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > g.config.MaxLockoutDuration {
+		duration = g.config.MaxLockoutDuration
+	}
+
+	log.Printf("Locking account %s for %s after %d failed logins, last one from %s", username, duration, failures, ip)
+	if err = g.repo.CreateLockout(&models.AccountLockout{
+		Username:       username,
+		UserID:         userID,
+		IP:             ip,
+		FailedAttempts: int(failures),
+		LockedUntil:    now.Add(duration),
+		CreatedAt:      now,
+	}); err != nil {
+		return err
+	}
+	// the next lockout starts counting from zero again
+	return g.repo.ClearUsernameFailures(username)
+}
+
+func (g *LoginGuard) RecordSuccess(username string) error {
+	return g.repo.ClearUsernameFailures(username)
+}
+
+func (g *LoginGuard) Unlock(user *models.User, adminID uint) error {
+	return g.repo.Unlock(user.Username, adminID, g.Now())
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionRevoked      = errors.New("session is revoked or expired")
+	ErrTokenOutdated       = errors.New("token was issued before the password change")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// lastSeenPrecision limits writes of the session last seen time to one per minute of activity.
+const lastSeenPrecision = time.Minute
+
+// ClientInfo describes the client which starts a session.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenManager issues short-lived access tokens together with rotating refresh tokens.
+// Every refresh token belongs to a server-side session, so revoking the session
+// invalidates all access tokens issued for it.
+type TokenManager struct {
+	keys       *KeyRing
+	users      *repositories.UserRepository
+	sessions   *repositories.SessionRepository
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenManager(
+	keys *KeyRing,
+	users *repositories.UserRepository,
+	sessions *repositories.SessionRepository,
+	accessTTL, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		keys:       keys,
+		users:      users,
+		sessions:   sessions,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// StartSession creates a new session for the user and returns its first token pair.
+func (m *TokenManager) StartSession(user *models.User, client ClientInfo) (*contracts.TokenPair, error) {
+	refreshToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: HashToken(refreshToken),
+		ExpiresAt:        now.Add(m.refreshTTL),
+		UserAgent:        client.UserAgent,
+		IP:               client.IP,
+		DeviceName:       DeviceName(client.UserAgent),
+		LastSeenAt:       now,
+	}
+	if err = m.sessions.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return m.tokenPair(user, session, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token becomes unusable;
+// presenting it again revokes the whole session, since that means the token chain leaked.
+func (m *TokenManager) Refresh(refreshToken string) (*contracts.TokenPair, error) {
+	hash := HashToken(refreshToken)
+	session, err := m.sessions.FindByRefreshTokenHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		reusedSession, err := m.sessions.FindByRotatedTokenHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		if reusedSession == nil {
+			return nil, ErrInvalidRefreshToken
+		}
+		if err = m.sessions.RevokeSession(reusedSession.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if !session.IsActive(time.Now()) {
+		return nil, ErrSessionRevoked
+	}
+
+	user, err := m.users.FindByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := m.sessions.RotateRefreshToken(session, HashToken(newRefreshToken), time.Now().Add(m.refreshTTL))
+	if err != nil {
+		return nil, fmt.Errorf("rotate refresh token: %w", err)
+	}
+	if !rotated {
+		// the same token was refreshed concurrently, which is a reuse like any other
+		if err = m.sessions.RevokeSession(session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	return m.tokenPair(user, session, newRefreshToken)
+}
+
+// Revoke ends the session which owns the refresh token.
+func (m *TokenManager) Revoke(refreshToken string) (*models.Session, error) {
+	session, err := m.sessions.FindByRefreshTokenHash(HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err = m.sessions.RevokeSession(session.ID); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RevokeAllSessions logs the user out everywhere, e.g. after the password was changed.
+func (m *TokenManager) RevokeAllSessions(userID uint) error {
+	return m.sessions.RevokeAllUserSessions(userID)
+}
+
+// ListSessions returns the devices the user is logged in on.
+func (m *TokenManager) ListSessions(userID uint) ([]models.Session, error) {
+	return m.sessions.ListActiveSessions(userID, time.Now())
+}
+
+// RevokeSession ends one active session of the user, sessions of other users are reported as not found.
+func (m *TokenManager) RevokeSession(userID, sessionID uint) (*models.Session, error) {
+	session, err := m.sessions.FindByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID || !session.IsActive(time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+	if err = m.sessions.RevokeSession(session.ID); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RevokeOtherSessions logs the user out everywhere except the current session.
+func (m *TokenManager) RevokeOtherSessions(userID, currentSessionID uint) (int64, error) {
+	return m.sessions.RevokeOtherUserSessions(userID, currentSessionID)
+}
+
+// ParseAccessToken verifies the token signature and checks that its session is still active.
+func (m *TokenManager) ParseAccessToken(tokenString string) (*contracts.Claims, error) {
+	claims := &contracts.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.VerificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		// the algorithm is taken from the key, never from the token header
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.Private.Public(), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !token.Valid || claims.SessionID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := m.sessions.FindByID(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if session == nil || session.UserID != claims.UserID || !session.IsActive(now) {
+		return nil, ErrSessionRevoked
+	}
+	if now.Sub(session.LastSeenAt) >= lastSeenPrecision {
+		// the last seen time is informational, so the token is still accepted if it can't be stored
+		if err = m.sessions.TouchSession(session.ID, now); err != nil {
+			log.Printf("Failed to update last seen time of session %d: %v", session.ID, err)
+		}
+	}
+
+	tokenVersion, ok, err := m.users.FindTokenVersion(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSessionRevoked
+	}
+	if claims.TokenVersion < tokenVersion {
+		return nil, ErrTokenOutdated
+	}
+	return claims, nil
+}
+
+func (m *TokenManager) tokenPair(user *models.User, session *models.Session, refreshToken string) (*contracts.TokenPair, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.accessTTL)
+	key := m.keys.Active()
+	token := jwt.NewWithClaims(key.Method, contracts.Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		SessionID:    session.ID,
+		Roles:        user.RoleNames(),
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	token.Header["kid"] = key.ID
+	jwtToken, err := token.SignedString(key.Private)
+	if err != nil {
+		return nil, err
+	}
+	return &contracts.TokenPair{
+		JwtToken:     jwtToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
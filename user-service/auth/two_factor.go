@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodesCount = 10
+	// maxChallengeAttempts wrong codes invalidate the login challenge
+	maxChallengeAttempts = 5
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrollment was not started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge        = errors.New("invalid or expired login challenge")
+)
+
+// TwoFactorManager implements TOTP based two-factor authentication.
+type TwoFactorManager struct {
+	repo         *repositories.TwoFactorRepository
+	users        *repositories.UserRepository
+	issuer       string
+	challengeTTL time.Duration
+	Now          func() time.Time
+}
+
+func NewTwoFactorManager(
+	repo *repositories.TwoFactorRepository,
+	users *repositories.UserRepository,
+	issuer string,
+	challengeTTL time.Duration) *TwoFactorManager {
+	return &TwoFactorManager{
+		repo:         repo,
+		users:        users,
+		issuer:       issuer,
+		challengeTTL: challengeTTL,
+		Now:          time.Now,
+	}
+}
+
+// Enroll generates a new secret. 2FA is not enabled until the secret is confirmed with a code.
+func (m *TwoFactorManager) Enroll(user *models.User) (secret string, provisioningURI string, err error) {
+	if user.TwoFactorEnabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+	secret, err = GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err = m.repo.SaveEnrollment(&models.TwoFactor{UserID: user.ID, Secret: secret}); err != nil {
+		return "", "", err
+	}
+	return secret, TOTPProvisioningURI(m.issuer, user.Username, secret), nil
+}
+
+// Confirm enables 2FA and returns one-time recovery codes, which are shown to the user only once.
+func (m *TwoFactorManager) Confirm(user *models.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	twoFactor, err := m.repo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	step, ok := validateTOTP(twoFactor.Secret, code, m.Now(), twoFactor.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	twoFactor.LastUsedStep = step
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.repo.Enable(twoFactor, hashes); err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabled = true
+	return codes, nil
+}
+
+// Disable turns 2FA off, the code may be either a TOTP or a recovery code.
+func (m *TwoFactorManager) Disable(user *models.User, code string) error {
+	if err := m.verifyCode(user, code); err != nil {
+		return err
+	}
+	if err := m.repo.Disable(user.ID); err != nil {
+		return err
+	}
+	user.TwoFactorEnabled = false
+	return nil
+}
+
+func (m *TwoFactorManager) RegenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if err := m.verifyCode(user, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.repo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CreateChallenge is called after the password was checked, the returned token
+// must be exchanged for real tokens together with a code.
+func (m *TwoFactorManager) CreateChallenge(user *models.User) (string, time.Time, error) {
+	token, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := m.Now().Add(m.challengeTTL)
+	err = m.repo.CreateChallenge(&models.LoginChallenge{
+		UserID:    user.ID,
+		TokenHash: HashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// CompleteChallenge returns the user who passed both factors. On ErrInvalidTwoFactorCode
+// the user is returned as well, so the caller can record the failed attempt.
+func (m *TwoFactorManager) CompleteChallenge(token, code string) (*models.User, error) {
+	challenge, err := m.repo.FindChallengeByHash(HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || challenge.UsedAt != nil ||
+		challenge.Attempts >= maxChallengeAttempts || m.Now().After(challenge.ExpiresAt) {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := m.users.FindByID(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err = m.verifyCode(user, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if incrementErr := m.repo.IncrementChallengeAttempts(challenge); incrementErr != nil {
+				return nil, incrementErr
+			}
+			return user, err
+		}
+		return nil, err
+	}
+
+	used, err := m.repo.UseChallenge(challenge, m.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidChallenge
+	}
+	return user, nil
+}
+
+func (m *TwoFactorManager) verifyCode(user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	twoFactor, err := m.repo.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	if twoFactor == nil || !twoFactor.Confirmed {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := validateTOTP(twoFactor.Secret, code, m.Now(), twoFactor.LastUsedStep); ok {
+		used, err := m.repo.UseStep(twoFactor, step)
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+		return ErrInvalidTwoFactorCode
+	}
+
+	used, err := m.repo.UseRecoveryCode(user.ID, HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodesCount; i++ {
+		buf := make([]byte, 10)
+		if _, err = rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var code strings.Builder
+		for j, b := range buf {
+			if j == len(buf)/2 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+		}
+		codes = append(codes, code.String())
+		hashes = append(hashes, HashToken(normalizeRecoveryCode(code.String())))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
@@ -0,0 +1,176 @@
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"social-network/user-service/models"
+	"social-network/user-service/storage"
+	"strconv"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image is too large")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// maxPixels guards against images which are small files but decode into huge bitmaps.
+const maxPixels = 40_000_000
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var fileNamePattern = regexp.MustCompile(`^[0-9a-f]{16}-[0-9]+\.jpg$`)
+
+// Service turns uploaded images into square JPEG thumbnails of models.AvatarSizes.
+// The thumbnails are encoded from the decoded pixels only, so EXIF and other metadata are dropped.
+type Service struct {
+	store    storage.BlobStore
+	maxBytes int64
+}
+
+func NewService(store storage.BlobStore, maxBytes int64) *Service {
+	return &Service{store: store, maxBytes: maxBytes}
+}
+
+func (s *Service) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Upload stores the thumbnails of the image and returns their version. The declared content type
+// comes from the client and has to agree with the sniffed one.
+func (s *Service) Upload(ctx context.Context, userID uint, data io.Reader, declaredType string) (string, error) {
+	content, err := io.ReadAll(io.LimitReader(data, s.maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(content)) > s.maxBytes {
+		return "", fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, s.maxBytes)
+	}
+	if err = checkContentType(content, declaredType); err != nil {
+		return "", err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return "", ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return "", fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, config.Width, config.Height)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	version, err := newVersion()
+	if err != nil {
+		return "", err
+	}
+	for i, size := range models.AvatarSizes {
+		var thumbnail bytes.Buffer
+		if err = jpeg.Encode(&thumbnail, squareThumbnail(decoded, size), &jpeg.Options{Quality: 85}); err == nil {
+			err = s.store.Put(ctx, models.AvatarKey(userID, version, size), &thumbnail)
+		}
+		if err != nil {
+			s.deleteSizes(ctx, userID, version, models.AvatarSizes[:i])
+			return "", err
+		}
+	}
+	return version, nil
+}
+
+// Delete removes the thumbnails of the version.
+func (s *Service) Delete(ctx context.Context, userID uint, version string) error {
+	return s.deleteSizes(ctx, userID, version, models.AvatarSizes)
+}
+
+// DeleteAll removes every avatar of the user, including ones left over by failed uploads.
+func (s *Service) DeleteAll(ctx context.Context, userID uint) error {
+	return s.store.DeletePrefix(ctx, fmt.Sprintf("avatars/%d/", userID))
+}
+
+// Open returns the thumbnail named as in the avatar URL, storage.ErrBlobNotFound if there is none.
+func (s *Service) Open(ctx context.Context, userID uint, fileName string) (io.ReadCloser, error) {
+	if !fileNamePattern.MatchString(fileName) {
+		return nil, storage.ErrBlobNotFound
+	}
+	return s.store.Get(ctx, "avatars/"+strconv.FormatUint(uint64(userID), 10)+"/"+fileName)
+}
+
+func (s *Service) deleteSizes(ctx context.Context, userID uint, version string, sizes []int) error {
+	var result error
+	for _, size := range sizes {
+		if err := s.store.Delete(ctx, models.AvatarKey(userID, version, size)); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+	return result
+}
+
+func checkContentType(content []byte, declaredType string) error {
+	sniffed := http.DetectContentType(content)
+	if !allowedTypes[sniffed] {
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, sniffed)
+	}
+	if declaredType == "" {
+		return nil
+	}
+	declared, _, err := mime.ParseMediaType(declaredType)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, declaredType)
+	}
+	if declared == "image/jpg" {
+		declared = "image/jpeg"
+	}
+	if declared != sniffed {
+		return fmt.Errorf("%w: declared %s, but the content is %s", ErrUnsupportedType, declared, sniffed)
+	}
+	return nil
+}
+
+// squareThumbnail crops the center square of the image and scales it to size,
+// transparent areas become white since JPEG has no alpha channel.
+func squareThumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	return dst
+}
+
+func newVersion() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,45 @@
+package contracts
+
+import (
+	"social-network/user-service/models"
+	"time"
+)
+
+type CreateAccessTokenRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// tokens without an expiry live until they are revoked
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+type AccessToken struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewAccessToken(token *models.PersonalAccessToken) AccessToken {
+	return AccessToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+type CreateAccessTokenResponse struct {
+	AccessToken
+	// Token is shown only once, the server keeps just its hash
+	Token string `json:"token"`
+}
+
+type ListAccessTokensResponse struct {
+	Tokens []AccessToken `json:"tokens"`
+}
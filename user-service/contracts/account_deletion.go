@@ -0,0 +1,6 @@
+package contracts
+
+type DeleteAccountRequest struct {
+	// the password is asked again, so a stolen access token is not enough to delete the account
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,10 @@
+package contracts
+
+import "social-network/user-service/models"
+
+type ListAuditEventsResponse struct {
+	Events     []models.AuditEvent `json:"events"`
+	TotalCount int64               `json:"total_count"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+}
@@ -3,17 +3,21 @@ package contracts
 import (
 	"social-network/user-service/models"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
-	// max=72 because of https://pkg.go.dev/golang.org/x/crypto/bcrypt@v0.35.0#GenerateFromPassword
-	Password    string     `json:"password" binding:"required,min=6,max=72"`
+	// the length and the rest of the rules are checked by the password policy, max only bounds the input of the hasher
+	Password    string     `json:"password" binding:"required,max=256"`
 	FirstName   string     `json:"first_name" binding:"omitempty,max=50"`
 	LastName    string     `json:"last_name" binding:"omitempty,max=50"`
 	Email       string     `json:"email" binding:"required,email"`
 	BirthDate   *time.Time `json:"birth_date" binding:"omitempty" time_format:"1970-01-01"`
 	PhoneNumber string     `json:"phone_number" binding:"omitempty"`
+	// required while registration is invite-only, otherwise it only records who invited the user
+	InviteCode string `json:"invite_code" binding:"omitempty,max=100"`
 }
 
 type LoginRequest struct {
@@ -27,14 +31,69 @@ type UpdateProfileRequest struct {
 	Email       string     `json:"email" binding:"omitempty,email"`
 	BirthDate   *time.Time `json:"birth_date" binding:"omitempty"`
 	PhoneNumber string     `json:"phone_number" binding:"omitempty"`
+	DisplayName string     `json:"display_name" binding:"omitempty,max=50"`
+	Bio         string     `json:"bio" binding:"omitempty,max=500"`
+	Location    string     `json:"location" binding:"omitempty,max=100"`
+	// Website and Links take absolute http or https URLs, links replace the current ones
+	Website string   `json:"website" binding:"omitempty,max=200"`
+	Links   []string `json:"links" binding:"omitempty,max=5,unique,dive,required,max=200"`
+	// empty fields are left as they are, fields listed in Clear are emptied
+	Clear []string `json:"clear" binding:"omitempty,dive,oneof=first_name last_name display_name bio location website links birth_date phone_number"`
+	// visibility levels are public, followers or private, empty ones are left as they are
+	EmailVisibility       string `json:"email_visibility" binding:"omitempty,oneof=public followers private"`
+	PhoneNumberVisibility string `json:"phone_number_visibility" binding:"omitempty,oneof=public followers private"`
+	BirthDateVisibility   string `json:"birth_date_visibility" binding:"omitempty,oneof=public followers private"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenPair struct {
+	JwtToken     string    `json:"jwt_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
 
 type AuthResponse struct {
-	JwtToken string      `json:"jwt_token"`
-	User     models.User `json:"user"`
+	TokenPair
+	User models.User `json:"user"`
+}
+
+const (
+	TokenTypeAccess         = "access_token"
+	TokenTypePersonalAccess = "personal_access_token"
+)
+
+type IntrospectResponse struct {
+	Active        bool     `json:"active"`
+	TokenType     string   `json:"token_type,omitempty"`
+	UserID        uint     `json:"user_id,omitempty"`
+	Username      string   `json:"username,omitempty"`
+	SessionID     uint     `json:"session_id,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	EmailVerified bool     `json:"email_verified"`
+	TokenVersion  uint     `json:"token_version"`
+	// Restricted is set for suspended and banned users, their tokens must be rejected
+	Restricted bool `json:"restricted"`
+	// Scopes limit personal access tokens, access tokens aren't limited and have none
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint     `json:"user_id"`
+	Username  string   `json:"username"`
+	SessionID uint     `json:"sid"`
+	Roles     []string `json:"roles"`
+	// TokenVersion is the user's token version at the moment the token was issued
+	TokenVersion uint `json:"tver"`
+	jwt.RegisteredClaims
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
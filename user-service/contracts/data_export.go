@@ -0,0 +1,41 @@
+package contracts
+
+import (
+	"social-network/user-service/models"
+	"time"
+)
+
+// UserDataExport is everything user-service stores about a user,
+// the api-gateway puts it into the personal data export archive together with the user's posts.
+type UserDataExport struct {
+	Profile   models.User       `json:"profile"`
+	Relations ExportedRelations `json:"relations"`
+	Security  SecurityEvents    `json:"security"`
+}
+
+type ExportedRelation struct {
+	UserID    uint      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportedRelations struct {
+	Following []ExportedRelation `json:"following"`
+	Followers []ExportedRelation `json:"followers"`
+	Blocked   []ExportedRelation `json:"blocked"`
+	Muted     []ExportedRelation `json:"muted"`
+}
+
+type ExportedAccessToken struct {
+	AccessToken
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type SecurityEvents struct {
+	Sessions           []models.Session         `json:"sessions"`
+	LoginFailures      []models.LoginFailure    `json:"login_failures"`
+	Lockouts           []models.AccountLockout  `json:"lockouts"`
+	AccountDeletions   []models.AccountDeletion `json:"account_deletions"`
+	AuditLog           []models.AuditEvent      `json:"audit_log"`
+	AccessTokens       []ExportedAccessToken    `json:"access_tokens"`
+	TwoFactorEnabledAt *time.Time               `json:"two_factor_enabled_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package contracts
+
+type UserProfileResponse struct {
+	PublicProfile
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+	// IsFollowed is true if the viewer follows the user
+	IsFollowed bool `json:"is_followed"`
+}
+
+type FollowStatusResponse struct {
+	Following bool `json:"following"`
+}
@@ -0,0 +1,85 @@
+package contracts
+
+import (
+	"social-network/user-service/models"
+	"time"
+)
+
+type CreateInviteRequest struct {
+	// regular users can allow at most accounts.UserInviteMaxUses registrations per code
+	MaxUses int `json:"max_uses" binding:"required,min=1,max=1000"`
+	// codes without an expiry live until they are used up or revoked
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// Invitee is a user who registered with an invite code.
+type Invitee struct {
+	ID       uint      `json:"id"`
+	Username string    `json:"username"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type Invite struct {
+	ID        uint       `json:"id"`
+	Prefix    string     `json:"prefix"`
+	MaxUses   int        `json:"max_uses"`
+	UsedCount int        `json:"used_count"`
+	Active    bool       `json:"active"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Invitees  []Invitee  `json:"invitees"`
+}
+
+func NewInvite(invite *models.InviteCode, now time.Time) Invite {
+	return Invite{
+		ID:        invite.ID,
+		Prefix:    invite.Prefix,
+		MaxUses:   invite.MaxUses,
+		UsedCount: invite.UsedCount,
+		Active:    invite.IsUsable(now),
+		ExpiresAt: invite.ExpiresAt,
+		RevokedAt: invite.RevokedAt,
+		CreatedAt: invite.CreatedAt,
+		Invitees:  []Invitee{},
+	}
+}
+
+// NewInvites attaches every invitee to the code they registered with.
+func NewInvites(invites []models.InviteCode, invitees []models.User, now time.Time) []Invite {
+	result := make([]Invite, len(invites))
+	byID := make(map[uint]*Invite, len(invites))
+	for i := range invites {
+		result[i] = NewInvite(&invites[i], now)
+		byID[invites[i].ID] = &result[i]
+	}
+	for _, invitee := range invitees {
+		if invitee.InviteCodeID == nil {
+			continue
+		}
+		if invite, ok := byID[*invitee.InviteCodeID]; ok {
+			invite.Invitees = append(invite.Invitees, Invitee{
+				ID:       invitee.ID,
+				Username: invitee.Username,
+				JoinedAt: invitee.CreatedAt,
+			})
+		}
+	}
+	return result
+}
+
+type CreateInviteResponse struct {
+	Invite
+	// Code is shown only once, the server keeps just its hash
+	Code string `json:"code"`
+}
+
+type ListInvitesResponse struct {
+	Invites []Invite `json:"invites"`
+}
+
+// UserInvitesResponse tells admins who invited the user and whom the user invited.
+type UserInvitesResponse struct {
+	InvitedByID *uint    `json:"invited_by_id,omitempty"`
+	Invites     []Invite `json:"invites"`
+}
@@ -0,0 +1,62 @@
+package contracts
+
+import (
+	"social-network/user-service/models"
+	"time"
+)
+
+type SuspendUserRequest struct {
+	Reason string    `json:"reason" binding:"required,max=500"`
+	Until  time.Time `json:"until" binding:"required"`
+}
+
+type BanUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type ReinstateUserRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ModerationStatus is the current restriction of a user, Reason comes from the latest suspension or ban.
+type ModerationStatus struct {
+	Banned         bool       `json:"banned"`
+	BannedAt       *time.Time `json:"banned_at,omitempty"`
+	Suspended      bool       `json:"suspended"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+}
+
+func NewModerationStatus(user *models.User, now time.Time) ModerationStatus {
+	status := ModerationStatus{
+		Banned:    user.IsBanned(),
+		BannedAt:  user.BannedAt,
+		Suspended: user.IsSuspended(now),
+	}
+	if status.Suspended {
+		status.SuspendedUntil = user.SuspendedUntil
+	}
+	if status.Banned || status.Suspended {
+		status.Reason = user.ModerationReason
+	}
+	return status
+}
+
+type ModerationActionResponse struct {
+	Action models.ModerationAction `json:"action"`
+	Status ModerationStatus        `json:"status"`
+}
+
+type UserModerationResponse struct {
+	Status     ModerationStatus          `json:"status"`
+	Actions    []models.ModerationAction `json:"actions"`
+	TotalCount int64                     `json:"total_count"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"page_size"`
+}
+
+// AccountRestrictedResponse is returned on login attempts of suspended and banned users.
+type AccountRestrictedResponse struct {
+	Error string `json:"error"`
+	ModerationStatus
+}
@@ -0,0 +1,25 @@
+package contracts
+
+import "social-network/user-service/passwords"
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// the length and the rest of the rules are checked by the password policy, max only bounds the input of the hasher
+	NewPassword string `json:"new_password" binding:"required,max=256"`
+}
+
+type ResetPasswordRequest struct {
+	Token string `json:"token" binding:"required"`
+	// the length and the rest of the rules are checked by the password policy, max only bounds the input of the hasher
+	NewPassword string `json:"new_password" binding:"required,max=256"`
+}
+
+// PasswordPolicyErrorResponse lists every rule of the password policy the new password breaks.
+type PasswordPolicyErrorResponse struct {
+	Error      string                `json:"error"`
+	Violations []passwords.Violation `json:"violations"`
+}
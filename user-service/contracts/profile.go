@@ -0,0 +1,67 @@
+package contracts
+
+import (
+	"social-network/user-service/models"
+	"time"
+)
+
+// PublicProfile is what other users see, hidden fields are omitted.
+type PublicProfile struct {
+	ID          uint       `json:"id"`
+	Username    string     `json:"username"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	DisplayName string     `json:"display_name"`
+	Bio         string     `json:"bio"`
+	Location    string     `json:"location"`
+	Website     string     `json:"website"`
+	Links       []string   `json:"links,omitempty"`
+	Email       string     `json:"email,omitempty"`
+	BirthDate   *time.Time `json:"birth_date,omitempty"`
+	PhoneNumber string     `json:"phone_number,omitempty"`
+	// AvatarURLs are public like the username
+	AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// ListUsersResponse is a page of public profiles.
+type ListUsersResponse struct {
+	Users      []PublicProfile `json:"users"`
+	TotalCount int64           `json:"total_count"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+}
+
+// NewPublicProfile projects the user for viewerID, the owner sees all of their fields.
+// follower tells whether the viewer follows the user, which reveals fields shown to followers.
+func NewPublicProfile(user *models.User, viewerID uint, follower bool) PublicProfile {
+	isOwner := user.ID == viewerID
+	profile := PublicProfile{
+		ID:          user.ID,
+		Username:    user.Username,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Location:    user.Location,
+		Website:     user.Website,
+		Links:       user.Links,
+		AvatarURLs:  user.AvatarURLs,
+		CreatedAt:   user.CreatedAt,
+	}
+	if isOwner || models.IsVisible(user.EmailVisibility, follower) {
+		profile.Email = user.Email
+	}
+	if isOwner || models.IsVisible(user.BirthDateVisibility, follower) {
+		profile.BirthDate = user.BirthDate
+	}
+	if isOwner || models.IsVisible(user.PhoneNumberVisibility, follower) {
+		profile.PhoneNumber = user.PhoneNumber
+	}
+	return profile
+}
+
+// AvatarResponse maps thumbnail sizes in pixels to their URLs.
+type AvatarResponse struct {
+	AvatarURLs map[string]string `json:"avatar_urls"`
+}
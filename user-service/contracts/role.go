@@ -0,0 +1,5 @@
+package contracts
+
+type GrantRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
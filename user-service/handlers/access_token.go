@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokenHandler struct {
+	UserRepo     *repositories.UserRepository
+	AccessTokens *auth.AccessTokenManager
+	AuditRepo    *repositories.AuditRepository
+}
+
+func NewAccessTokenHandler(
+	userRepo *repositories.UserRepository,
+	accessTokens *auth.AccessTokenManager,
+	auditRepo *repositories.AuditRepository) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		UserRepo:     userRepo,
+		AccessTokens: accessTokens,
+		AuditRepo:    auditRepo,
+	}
+}
+
+func (h *AccessTokenHandler) CreateToken(c *gin.Context) {
+	var createRequest contracts.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&createRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during CreateToken.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if createRequest.ExpiresInDays != nil {
+		expiration := h.AccessTokens.Now().AddDate(0, 0, *createRequest.ExpiresInDays)
+		expiresAt = &expiration
+	}
+	token, accessToken, err := h.AccessTokens.Create(user, createRequest.Name, createRequest.Scopes, expiresAt)
+	if errors.Is(err, auth.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during CreateToken.Create: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contracts.CreateAccessTokenResponse{
+		AccessToken: contracts.NewAccessToken(accessToken),
+		Token:       token,
+	})
+}
+
+func (h *AccessTokenHandler) ListTokens(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during ListTokens.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	accessTokens, err := h.AccessTokens.List(user)
+	if err != nil {
+		log.Printf("Error during ListTokens.List: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list access tokens"})
+		return
+	}
+	response := contracts.ListAccessTokensResponse{Tokens: make([]contracts.AccessToken, len(accessTokens))}
+	for i := range accessTokens {
+		response.Tokens[i] = contracts.NewAccessToken(&accessTokens[i])
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AccessTokenHandler) RevokeToken(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("tokenId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token id"})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during RevokeToken.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	revoked, err := h.AccessTokens.Revoke(user, uint(tokenID))
+	if err != nil {
+		log.Printf("Error during RevokeToken.Revoke: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access token"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Access token not found"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditAccessTokenRevoked, fmt.Sprintf("access token %d", tokenID))
+	c.JSON(http.StatusOK, gin.H{"message": "Access token revoked"})
+}
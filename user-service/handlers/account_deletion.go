@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"social-network/user-service/accounts"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountDeletionHandler struct {
+	UserRepo  *repositories.UserRepository
+	Deleter   *accounts.Deleter
+	AuditRepo *repositories.AuditRepository
+}
+
+func NewAccountDeletionHandler(
+	userRepo *repositories.UserRepository,
+	deleter *accounts.Deleter,
+	auditRepo *repositories.AuditRepository) *AccountDeletionHandler {
+	return &AccountDeletionHandler{
+		UserRepo:  userRepo,
+		Deleter:   deleter,
+		AuditRepo: auditRepo,
+	}
+}
+
+// DeleteAccount schedules the deletion, the account is erased after the grace period.
+func (h *AccountDeletionHandler) DeleteAccount(c *gin.Context) {
+	var deleteRequest contracts.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&deleteRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during DeleteAccount.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	passwordValid, _, err := h.UserRepo.CheckPassword(user, deleteRequest.Password)
+	if err != nil {
+		log.Printf("Error during DeleteAccount.CheckPassword: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking password"})
+		return
+	}
+	if !passwordValid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	deletion, err := h.Deleter.Schedule(user)
+	if errors.Is(err, accounts.ErrDeletionAlreadyScheduled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already scheduled"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during DeleteAccount.Schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditDeletionScheduled,
+		"scheduled for "+deletion.ScheduledFor.UTC().Format(time.RFC3339))
+	c.JSON(http.StatusAccepted, deletion)
+}
+
+func (h *AccountDeletionHandler) GetDeletion(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during GetDeletion.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	deletion, err := h.Deleter.Pending(user)
+	if err != nil {
+		log.Printf("Error during GetDeletion.Pending: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get account deletion"})
+		return
+	}
+	if deletion == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account deletion is not scheduled"})
+		return
+	}
+	c.JSON(http.StatusOK, deletion)
+}
+
+func (h *AccountDeletionHandler) CancelDeletion(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during CancelDeletion.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err = h.Deleter.Cancel(user)
+	if errors.Is(err, accounts.ErrNoPendingDeletion) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account deletion is not scheduled"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during CancelDeletion.Cancel: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditDeletionCanceled, "")
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion canceled"})
+}
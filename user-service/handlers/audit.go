@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	AuditRepo *repositories.AuditRepository
+}
+
+func NewAuditHandler(auditRepo *repositories.AuditRepository) *AuditHandler {
+	return &AuditHandler{AuditRepo: auditRepo}
+}
+
+// ListOwnEvents returns the recent security events of the current user.
+func (h *AuditHandler) ListOwnEvents(c *gin.Context) {
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+	h.respondEvents(c, "ListOwnEvents", repositories.AuditFilter{UserID: c.GetUint("userID")}, page, pageSize)
+}
+
+// ListEvents lets admins filter the audit log by userId, type and the [from, to) time range.
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var filter repositories.AuditFilter
+	if userID := c.Query("userId"); userID != "" {
+		id, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+			return
+		}
+		filter.UserID = uint(id)
+	}
+	filter.Type = c.Query("type")
+	if filter.Type != "" && !slices.Contains(models.AuditEventTypes, filter.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type"})
+		return
+	}
+	if filter.From, ok = timeFromQuery(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = timeFromQuery(c, "to"); !ok {
+		return
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	h.respondEvents(c, "ListEvents", filter, page, pageSize)
+}
+
+// timeFromQuery reads an optional RFC 3339 time, it responds with 400 if the value is invalid.
+func timeFromQuery(c *gin.Context, param string) (*time.Time, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC 3339 time"})
+		return nil, false
+	}
+	return &parsed, true
+}
+
+func (h *AuditHandler) respondEvents(c *gin.Context, method string, filter repositories.AuditFilter, page, pageSize int) {
+	events, totalCount, err := h.AuditRepo.ListEvents(filter, page, pageSize)
+	if err != nil {
+		log.Printf("Error during %s.ListEvents: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list security events"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.ListAuditEventsResponse{
+		Events:     events,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+// recordAuditEvent appends the event to the user's audit log with the client of the request.
+// A failure is only logged, the action itself has already happened.
+func recordAuditEvent(c *gin.Context, auditRepo *repositories.AuditRepository, userID uint, eventType, details string) {
+	err := auditRepo.Create(&models.AuditEvent{
+		UserID:    userID,
+		Type:      eventType,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Details:   details,
+	})
+	if err != nil {
+		log.Printf("Error during recordAuditEvent.Create: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"social-network/user-service/avatars"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"social-network/user-service/storage"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is allowed on top of the image size for the multipart boundaries and headers.
+const multipartOverhead = 64 << 10
+
+type AvatarHandler struct {
+	UserRepo *repositories.UserRepository
+	Avatars  *avatars.Service
+}
+
+func NewAvatarHandler(userRepo *repositories.UserRepository, avatars *avatars.Service) *AvatarHandler {
+	return &AvatarHandler{UserRepo: userRepo, Avatars: avatars}
+}
+
+// UploadAvatar replaces the avatar with the image from the "avatar" multipart field.
+func (h *AvatarHandler) UploadAvatar(c *gin.Context) {
+	user := h.currentUser(c, "UploadAvatar")
+	if user == nil {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.Avatars.MaxBytes()+multipartOverhead)
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error during UploadAvatar.Open: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read avatar"})
+		return
+	}
+	defer file.Close()
+
+	version, err := h.Avatars.Upload(c.Request.Context(), user.ID, file, fileHeader.Header.Get("Content-Type"))
+	switch {
+	case errors.Is(err, avatars.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Avatar must be a JPEG, PNG, GIF or WebP image"})
+		return
+	case errors.Is(err, avatars.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, avatars.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar is not a valid image"})
+		return
+	case err != nil:
+		log.Printf("Error during UploadAvatar.Upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+		return
+	}
+
+	previousVersion := user.AvatarVersion
+	if err = h.UserRepo.UpdateAvatar(user, version); err != nil {
+		log.Printf("Error during UploadAvatar.UpdateAvatar: %v", err)
+		if err = h.Avatars.Delete(c.Request.Context(), user.ID, version); err != nil {
+			log.Printf("Error during UploadAvatar.Delete: %v", err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+		return
+	}
+	if previousVersion != "" {
+		if err = h.Avatars.Delete(c.Request.Context(), user.ID, previousVersion); err != nil {
+			log.Printf("Error during UploadAvatar.Delete: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, contracts.AvatarResponse{AvatarURLs: user.AvatarURLs})
+}
+
+func (h *AvatarHandler) DeleteAvatar(c *gin.Context) {
+	user := h.currentUser(c, "DeleteAvatar")
+	if user == nil {
+		return
+	}
+	if user.AvatarVersion == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+
+	version := user.AvatarVersion
+	if err := h.UserRepo.UpdateAvatar(user, ""); err != nil {
+		log.Printf("Error during DeleteAvatar.UpdateAvatar: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete avatar"})
+		return
+	}
+	// the avatar is already gone from the profile, leftover files are only logged
+	if err := h.Avatars.Delete(c.Request.Context(), user.ID, version); err != nil {
+		log.Printf("Error during DeleteAvatar.Delete: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Avatar deleted"})
+}
+
+func (h *AvatarHandler) currentUser(c *gin.Context, method string) *models.User {
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during %s.FindByID: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return nil
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil
+	}
+	return user
+}
+
+// GetAvatar serves a thumbnail, it is public since avatar URLs are part of public profiles.
+func (h *AvatarHandler) GetAvatar(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+
+	image, err := h.Avatars.Open(c.Request.Context(), uint(userID), c.Param("file"))
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during GetAvatar.Open: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read avatar"})
+		return
+	}
+	defer image.Close()
+
+	c.Header("Content-Type", "image/jpeg")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	if _, err = io.Copy(c.Writer, image); err != nil {
+		log.Printf("Error during GetAvatar.Copy: %v", err)
+	}
+}
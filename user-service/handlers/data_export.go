@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExportHandler struct {
+	UserRepo   *repositories.UserRepository
+	ExportRepo *repositories.DataExportRepository
+}
+
+func NewDataExportHandler(userRepo *repositories.UserRepository, exportRepo *repositories.DataExportRepository) *DataExportHandler {
+	return &DataExportHandler{
+		UserRepo:   userRepo,
+		ExportRepo: exportRepo,
+	}
+}
+
+// ExportUserData is used by the api-gateway, which builds the personal data export archive.
+func (h *DataExportHandler) ExportUserData(c *gin.Context) {
+	user := findPathUser(c, h.UserRepo, "ExportUserData")
+	if user == nil {
+		return
+	}
+	export, err := h.collect(user.ID)
+	if err != nil {
+		log.Printf("Error during ExportUserData.collect: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export user data"})
+		return
+	}
+	export.Profile = *user
+	c.JSON(http.StatusOK, export)
+}
+
+func (h *DataExportHandler) collect(userID uint) (*contracts.UserDataExport, error) {
+	export := &contracts.UserDataExport{
+		Relations: contracts.ExportedRelations{
+			Following: []contracts.ExportedRelation{},
+			Followers: []contracts.ExportedRelation{},
+			Blocked:   []contracts.ExportedRelation{},
+			Muted:     []contracts.ExportedRelation{},
+		},
+	}
+
+	follows, err := h.ExportRepo.ListFollows(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, follow := range follows {
+		if follow.FollowerID == userID {
+			export.Relations.Following = append(export.Relations.Following,
+				contracts.ExportedRelation{UserID: follow.FolloweeID, CreatedAt: follow.CreatedAt})
+		} else {
+			export.Relations.Followers = append(export.Relations.Followers,
+				contracts.ExportedRelation{UserID: follow.FollowerID, CreatedAt: follow.CreatedAt})
+		}
+	}
+	blocks, err := h.ExportRepo.ListBlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		export.Relations.Blocked = append(export.Relations.Blocked,
+			contracts.ExportedRelation{UserID: block.BlockedID, CreatedAt: block.CreatedAt})
+	}
+	mutes, err := h.ExportRepo.ListMutes(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, mute := range mutes {
+		export.Relations.Muted = append(export.Relations.Muted,
+			contracts.ExportedRelation{UserID: mute.MutedID, CreatedAt: mute.CreatedAt})
+	}
+
+	security := &export.Security
+	if security.Sessions, err = h.ExportRepo.ListSessions(userID); err != nil {
+		return nil, err
+	}
+	if security.LoginFailures, err = h.ExportRepo.ListLoginFailures(userID); err != nil {
+		return nil, err
+	}
+	if security.Lockouts, err = h.ExportRepo.ListLockouts(userID); err != nil {
+		return nil, err
+	}
+	if security.AccountDeletions, err = h.ExportRepo.ListAccountDeletions(userID); err != nil {
+		return nil, err
+	}
+	if security.AuditLog, err = h.ExportRepo.ListAuditEvents(userID); err != nil {
+		return nil, err
+	}
+	accessTokens, err := h.ExportRepo.ListAccessTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+	security.AccessTokens = make([]contracts.ExportedAccessToken, len(accessTokens))
+	for i := range accessTokens {
+		security.AccessTokens[i] = contracts.ExportedAccessToken{
+			AccessToken: contracts.NewAccessToken(&accessTokens[i]),
+			RevokedAt:   accessTokens[i].RevokedAt,
+		}
+	}
+	twoFactor, err := h.ExportRepo.FindTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor != nil {
+		security.TwoFactorEnabledAt = &twoFactor.UpdatedAt
+	}
+	return export, nil
+}
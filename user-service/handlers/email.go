@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailHandler struct {
+	UserRepo      *repositories.UserRepository
+	EmailVerifier *auth.EmailVerifier
+}
+
+func NewEmailHandler(userRepo *repositories.UserRepository, emailVerifier *auth.EmailVerifier) *EmailHandler {
+	return &EmailHandler{
+		UserRepo:      userRepo,
+		EmailVerifier: emailVerifier,
+	}
+}
+
+func (h *EmailHandler) VerifyEmail(c *gin.Context) {
+	var verifyRequest contracts.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&verifyRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := h.EmailVerifier.Verify(verifyRequest.Token)
+	if errors.Is(err, auth.ErrInvalidVerificationToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during VerifyEmail.Verify: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(userID)
+	if err != nil {
+		log.Printf("Error during VerifyEmail.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *EmailHandler) ResendVerification(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(userID.(uint))
+	if err != nil {
+		log.Printf("Error during ResendVerification.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	if err = h.EmailVerifier.SendVerification(user); err != nil {
+		log.Printf("Error during ResendVerification.SendVerification: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Verification token was sent"})
+}
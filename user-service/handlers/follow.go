@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowHandler struct {
+	UserRepo     *repositories.UserRepository
+	FollowRepo   *repositories.FollowRepository
+	RelationRepo *repositories.RelationRepository
+}
+
+func NewFollowHandler(
+	userRepo *repositories.UserRepository,
+	followRepo *repositories.FollowRepository,
+	relationRepo *repositories.RelationRepository) *FollowHandler {
+	return &FollowHandler{
+		UserRepo:     userRepo,
+		FollowRepo:   followRepo,
+		RelationRepo: relationRepo,
+	}
+}
+
+func (h *FollowHandler) Follow(c *gin.Context) {
+	followee := findPathUser(c, h.UserRepo, "Follow")
+	if followee == nil {
+		return
+	}
+	followerID := c.GetUint("userID")
+	if followee.ID == followerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't follow yourself"})
+		return
+	}
+	blocked, err := h.RelationRepo.IsBlockedEitherWay(followerID, followee.ID)
+	if err != nil {
+		log.Printf("Error during Follow.IsBlockedEitherWay: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't follow this user"})
+		return
+	}
+
+	if _, err := h.FollowRepo.Follow(followerID, followee.ID); err != nil {
+		log.Printf("Error during Follow.Follow: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.FollowStatusResponse{Following: true})
+}
+
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	followee := findPathUser(c, h.UserRepo, "Unfollow")
+	if followee == nil {
+		return
+	}
+
+	if _, err := h.FollowRepo.Unfollow(c.GetUint("userID"), followee.ID); err != nil {
+		log.Printf("Error during Unfollow.Unfollow: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.FollowStatusResponse{Following: false})
+}
+
+func (h *FollowHandler) ListFollowers(c *gin.Context) {
+	h.listFollows(c, "ListFollowers", h.FollowRepo.ListFollowers)
+}
+
+func (h *FollowHandler) ListFollowing(c *gin.Context) {
+	h.listFollows(c, "ListFollowing", h.FollowRepo.ListFollowing)
+}
+
+// IsFollowing checks whether the user from the path follows the target user.
+func (h *FollowHandler) IsFollowing(c *gin.Context) {
+	followerID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+	followeeID, err := strconv.ParseUint(c.Param("targetId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target user id"})
+		return
+	}
+
+	// follows of users who blocked the viewer are as hidden as their profiles
+	for _, userID := range []uint{uint(followerID), uint(followeeID)} {
+		if !h.checkNotBlocked(c, "IsFollowing", userID) {
+			return
+		}
+	}
+
+	following, err := h.FollowRepo.IsFollowing(uint(followerID), uint(followeeID))
+	if err != nil {
+		log.Printf("Error during IsFollowing.IsFollowing: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check follow"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.FollowStatusResponse{Following: following})
+}
+
+func (h *FollowHandler) listFollows(
+	c *gin.Context,
+	method string,
+	list func(userID, viewerID uint, page, pageSize int) ([]models.User, int64, error)) {
+	user := findPathUser(c, h.UserRepo, method)
+	if user == nil || !h.checkNotBlocked(c, method, user.ID) {
+		return
+	}
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+
+	users, totalCount, err := list(user.ID, c.GetUint("userID"), page, pageSize)
+	if err != nil {
+		log.Printf("Error during %s: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	profiles, err := publicProfiles(h.FollowRepo, users, c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during %s.publicProfiles: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.ListUsersResponse{
+		Users:      profiles,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+// checkNotBlocked responds with 404 if the user blocked the viewer, like respondPublicProfile does.
+func (h *FollowHandler) checkNotBlocked(c *gin.Context, method string, userID uint) bool {
+	blocked, err := h.RelationRepo.IsBlocking(userID, c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during %s.IsBlocking: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return false
+	}
+	if blocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return false
+	}
+	return true
+}
+
+// findPathUser loads the user with the id from the path, it responds with an error if there is no such user.
+func findPathUser(c *gin.Context, userRepo *repositories.UserRepository, method string) *models.User {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return nil
+	}
+	user, err := userRepo.FindByID(uint(userID))
+	if err != nil {
+		log.Printf("Error during %s.FindByID: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return nil
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil
+	}
+	return user
+}
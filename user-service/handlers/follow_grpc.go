@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"social-network/common/proto"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FollowGRPCHandler exposes the follow graph to other services.
+type FollowGRPCHandler struct {
+	repo *repositories.FollowRepository
+	proto.UnimplementedFollowServiceServer
+}
+
+func NewFollowGRPCHandler(repo *repositories.FollowRepository) *FollowGRPCHandler {
+	return &FollowGRPCHandler{repo: repo}
+}
+
+func (h *FollowGRPCHandler) IsFollowing(ctx context.Context, req *proto.IsFollowingRequest) (*proto.IsFollowingResponse, error) {
+	following, err := h.repo.IsFollowing(uint(req.FollowerId), uint(req.FolloweeId))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to check follow: %v", err)
+	}
+	return &proto.IsFollowingResponse{Following: following}, nil
+}
+
+func (h *FollowGRPCHandler) ListFollowers(ctx context.Context, req *proto.ListFollowsRequest) (*proto.ListFollowsResponse, error) {
+	return h.listFollows(req, h.repo.ListFollowers)
+}
+
+func (h *FollowGRPCHandler) ListFollowing(ctx context.Context, req *proto.ListFollowsRequest) (*proto.ListFollowsResponse, error) {
+	return h.listFollows(req, h.repo.ListFollowing)
+}
+
+func (h *FollowGRPCHandler) GetFollowCounts(ctx context.Context, req *proto.GetFollowCountsRequest) (*proto.FollowCounts, error) {
+	followers, err := h.repo.CountFollowers(uint(req.UserId))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to count followers: %v", err)
+	}
+	following, err := h.repo.CountFollowing(uint(req.UserId))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to count following: %v", err)
+	}
+	return &proto.FollowCounts{FollowersCount: followers, FollowingCount: following}, nil
+}
+
+func (h *FollowGRPCHandler) listFollows(
+	req *proto.ListFollowsRequest,
+	list func(userID, viewerID uint, page, pageSize int) ([]models.User, int64, error)) (*proto.ListFollowsResponse, error) {
+	page := int(req.Page)
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 1000 {
+		return nil, status.Errorf(codes.InvalidArgument, "Page size must not exceed 1000")
+	}
+
+	// other services ask on their own behalf, so nobody is hidden
+	users, totalCount, err := list(uint(req.UserId), 0, page, pageSize)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to list follows: %v", err)
+	}
+	userIDs := make([]uint64, len(users))
+	for i, user := range users {
+		userIDs[i] = uint64(user.ID)
+	}
+	return &proto.ListFollowsResponse{
+		UserIds:    userIDs,
+		TotalCount: int32(totalCount),
+		TotalPages: int32((totalCount + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
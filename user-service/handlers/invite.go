@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"social-network/user-service/accounts"
+	"social-network/user-service/contracts"
+	"social-network/user-service/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InviteHandler struct {
+	UserRepo *repositories.UserRepository
+	Inviter  *accounts.Inviter
+}
+
+func NewInviteHandler(userRepo *repositories.UserRepository, inviter *accounts.Inviter) *InviteHandler {
+	return &InviteHandler{
+		UserRepo: userRepo,
+		Inviter:  inviter,
+	}
+}
+
+func (h *InviteHandler) CreateInvite(c *gin.Context) {
+	var createRequest contracts.CreateInviteRequest
+	if err := c.ShouldBindJSON(&createRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during CreateInvite.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if createRequest.ExpiresInDays != nil {
+		expiration := h.Inviter.Now().AddDate(0, 0, *createRequest.ExpiresInDays)
+		expiresAt = &expiration
+	}
+	code, invite, err := h.Inviter.Create(user, createRequest.MaxUses, expiresAt)
+	if errors.Is(err, accounts.ErrInviteLimitTooBig) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invite codes of users allow at most %d registrations", accounts.UserInviteMaxUses)})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during CreateInvite.Create: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite code"})
+		return
+	}
+	c.JSON(http.StatusCreated, contracts.CreateInviteResponse{
+		Invite: contracts.NewInvite(invite, h.Inviter.Now()),
+		Code:   code,
+	})
+}
+
+func (h *InviteHandler) ListInvites(c *gin.Context) {
+	invites, invitees, err := h.Inviter.List(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during ListInvites.List: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list invite codes"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.ListInvitesResponse{
+		Invites: contracts.NewInvites(invites, invitees, h.Inviter.Now()),
+	})
+}
+
+func (h *InviteHandler) RevokeInvite(c *gin.Context) {
+	inviteID, err := strconv.ParseUint(c.Param("inviteId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite id"})
+		return
+	}
+
+	revoked, err := h.Inviter.Revoke(c.GetUint("userID"), uint(inviteID))
+	if err != nil {
+		log.Printf("Error during RevokeInvite.Revoke: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite code"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite code not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invite code revoked"})
+}
+
+// GetUserInvites shows admins who invited the user and whom the user invited.
+func (h *InviteHandler) GetUserInvites(c *gin.Context) {
+	user := findPathUser(c, h.UserRepo, "GetUserInvites")
+	if user == nil {
+		return
+	}
+	invites, invitees, err := h.Inviter.List(user.ID)
+	if err != nil {
+		log.Printf("Error during GetUserInvites.List: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list invite codes"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.UserInvitesResponse{
+		InvitedByID: user.InvitedByID,
+		Invites:     contracts.NewInvites(invites, invitees, h.Inviter.Now()),
+	})
+}
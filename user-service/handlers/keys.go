@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"social-network/user-service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeysHandler struct {
+	Keys *auth.KeyRing
+}
+
+func NewKeysHandler(keys *auth.KeyRing) *KeysHandler {
+	return &KeysHandler{Keys: keys}
+}
+
+// JWKS publishes the public keys which verify access tokens, verifiers refetch it when they see an unknown kid.
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, h.Keys.JWKS())
+}
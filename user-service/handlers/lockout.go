@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LockoutHandler struct {
+	UserRepo         *repositories.UserRepository
+	LoginAttemptRepo *repositories.LoginAttemptRepository
+	LoginGuard       *auth.LoginGuard
+}
+
+func NewLockoutHandler(
+	userRepo *repositories.UserRepository,
+	loginAttemptRepo *repositories.LoginAttemptRepository,
+	loginGuard *auth.LoginGuard) *LockoutHandler {
+	return &LockoutHandler{
+		UserRepo:         userRepo,
+		LoginAttemptRepo: loginAttemptRepo,
+		LoginGuard:       loginGuard,
+	}
+}
+
+func (h *LockoutHandler) UnlockUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(uint(userID))
+	if err != nil {
+		log.Printf("Error during UnlockUser.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err = h.LoginGuard.Unlock(user, c.GetUint("userID")); err != nil {
+		log.Printf("Error during UnlockUser.Unlock: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+
+	lockouts, totalCount, err := h.LoginAttemptRepo.ListLockouts(page, pageSize, c.Query("username"), c.Query("ip"))
+	if err != nil {
+		log.Printf("Error during ListLockouts.ListLockouts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list lockouts"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.ListLockoutsResponse{
+		Lockouts:   lockouts,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"social-network/user-service/accounts"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+	UserRepo       *repositories.UserRepository
+	ModerationRepo *repositories.ModerationRepository
+	Moderator      *accounts.Moderator
+}
+
+func NewModerationHandler(
+	userRepo *repositories.UserRepository,
+	moderationRepo *repositories.ModerationRepository,
+	moderator *accounts.Moderator) *ModerationHandler {
+	return &ModerationHandler{
+		UserRepo:       userRepo,
+		ModerationRepo: moderationRepo,
+		Moderator:      moderator,
+	}
+}
+
+func (h *ModerationHandler) SuspendUser(c *gin.Context) {
+	var suspendRequest contracts.SuspendUserRequest
+	if err := c.ShouldBindJSON(&suspendRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := findPathUser(c, h.UserRepo, "SuspendUser")
+	if user == nil {
+		return
+	}
+
+	action, err := h.Moderator.Suspend(user, c.GetUint("userID"), suspendRequest.Reason, suspendRequest.Until)
+	h.respondAction(c, "SuspendUser", user, action, err)
+}
+
+func (h *ModerationHandler) BanUser(c *gin.Context) {
+	var banRequest contracts.BanUserRequest
+	if err := c.ShouldBindJSON(&banRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := findPathUser(c, h.UserRepo, "BanUser")
+	if user == nil {
+		return
+	}
+
+	action, err := h.Moderator.Ban(user, c.GetUint("userID"), banRequest.Reason)
+	h.respondAction(c, "BanUser", user, action, err)
+}
+
+func (h *ModerationHandler) ReinstateUser(c *gin.Context) {
+	var reinstateRequest contracts.ReinstateUserRequest
+	// the reason is optional, so the body may be left out
+	if err := c.ShouldBindJSON(&reinstateRequest); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := findPathUser(c, h.UserRepo, "ReinstateUser")
+	if user == nil {
+		return
+	}
+
+	action, err := h.Moderator.Reinstate(user, c.GetUint("userID"), reinstateRequest.Reason)
+	h.respondAction(c, "ReinstateUser", user, action, err)
+}
+
+// GetModeration returns the current restriction of the user and the history of moderation actions.
+func (h *ModerationHandler) GetModeration(c *gin.Context) {
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+	user := findPathUser(c, h.UserRepo, "GetModeration")
+	if user == nil {
+		return
+	}
+
+	actions, totalCount, err := h.ModerationRepo.ListActions(user.ID, page, pageSize)
+	if err != nil {
+		log.Printf("Error during GetModeration.ListActions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list moderation actions"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.UserModerationResponse{
+		Status:     contracts.NewModerationStatus(user, h.Moderator.Now()),
+		Actions:    actions,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+func (h *ModerationHandler) respondAction(
+	c *gin.Context, method string, user *models.User, action *models.ModerationAction, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrSelfModeration):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins can't suspend or ban themselves"})
+	case errors.Is(err, accounts.ErrInvalidSuspensionEnd):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Suspension must end in the future"})
+	case errors.Is(err, accounts.ErrNotRestricted):
+		c.JSON(http.StatusConflict, gin.H{"error": "User is neither suspended nor banned"})
+	case err != nil:
+		log.Printf("Error during %s: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to moderate user"})
+	default:
+		c.JSON(http.StatusOK, contracts.ModerationActionResponse{
+			Action: *action,
+			Status: contracts.NewModerationStatus(user, h.Moderator.Now()),
+		})
+	}
+}
+
+// rejectRestricted responds with 403 if the user is suspended or banned, the response tells them why and until when.
+func rejectRestricted(c *gin.Context, user *models.User) bool {
+	now := time.Now()
+	if !user.IsRestricted(now) {
+		return false
+	}
+	message := "Account is suspended"
+	if user.IsBanned() {
+		message = "Account is banned"
+	}
+	c.JSON(http.StatusForbidden, contracts.AccountRestrictedResponse{
+		Error:            message,
+		ModerationStatus: contracts.NewModerationStatus(user, now),
+	})
+	return true
+}
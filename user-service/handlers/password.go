@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/notifier"
+	"social-network/user-service/passwords"
+	"social-network/user-service/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	UserRepo  *repositories.UserRepository
+	ResetRepo *repositories.PasswordResetRepository
+	Tokens    *auth.TokenManager
+	Notifier  notifier.Notifier
+	ResetTTL  time.Duration
+	AuditRepo *repositories.AuditRepository
+	Policy    *passwords.Policy
+}
+
+func NewPasswordHandler(
+	userRepo *repositories.UserRepository,
+	resetRepo *repositories.PasswordResetRepository,
+	tokens *auth.TokenManager,
+	notifier notifier.Notifier,
+	resetTTL time.Duration,
+	auditRepo *repositories.AuditRepository,
+	policy *passwords.Policy) *PasswordHandler {
+	return &PasswordHandler{
+		UserRepo:  userRepo,
+		ResetRepo: resetRepo,
+		Tokens:    tokens,
+		Notifier:  notifier,
+		ResetTTL:  resetTTL,
+		AuditRepo: auditRepo,
+		Policy:    policy,
+	}
+}
+
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var forgotRequest contracts.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&forgotRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the response is the same whether the account exists or not, so emails can't be enumerated.
+	// Failures after the account is found are only logged, an error would reveal that it exists
+	response := gin.H{"message": "If the account exists, a reset token was sent to its email"}
+
+	user, err := h.UserRepo.FindByEmail(forgotRequest.Email)
+	if err != nil {
+		log.Printf("Error during ForgotPassword.FindByEmail: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		log.Printf("Error during ForgotPassword.GenerateOpaqueToken: %v", err)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	err = h.ResetRepo.CreateToken(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(h.ResetTTL),
+	})
+	if err != nil {
+		log.Printf("Error during ForgotPassword.CreateToken: %v", err)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	err = h.Notifier.Notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Use this token to reset the password of %s: %s\nThe token expires in %s.",
+			user.Username, token, h.ResetTTL),
+		Data: map[string]string{"password_reset_token": token},
+	})
+	if err != nil {
+		log.Printf("Error during ForgotPassword.Notify: %v", err)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var resetRequest contracts.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&resetRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.ResetRepo.FindByHash(auth.HashToken(resetRequest.Token))
+	if err != nil {
+		log.Printf("Error during ResetPassword.FindByHash: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking reset token"})
+		return
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(token.UserID)
+	if err != nil {
+		log.Printf("Error during ResetPassword.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	// checked before the token is used up, so the user can retry with a better password
+	if rejectWeakPassword(c, h.Policy, resetRequest.NewPassword, user) {
+		return
+	}
+
+	claimed, err := h.ResetRepo.MarkUsed(token)
+	if err != nil {
+		log.Printf("Error during ResetPassword.MarkUsed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking reset token"})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	if err = h.UserRepo.UpdatePassword(user, resetRequest.NewPassword); err != nil {
+		log.Printf("Error during ResetPassword.UpdatePassword: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	if err = h.Tokens.RevokeAllSessions(user.ID); err != nil {
+		log.Printf("Error during ResetPassword.RevokeAllSessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditPasswordReset, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password was reset"})
+}
+
+// ChangePassword logs the user out everywhere, the response contains tokens of a new session for the current client.
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var changeRequest contracts.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&changeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during ChangePassword.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	passwordValid, _, err := h.UserRepo.CheckPassword(user, changeRequest.CurrentPassword)
+	if err != nil {
+		log.Printf("Error during ChangePassword.CheckPassword: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking password"})
+		return
+	}
+	if !passwordValid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid current password"})
+		return
+	}
+	if changeRequest.NewPassword == changeRequest.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New password must differ from the current one"})
+		return
+	}
+	if rejectWeakPassword(c, h.Policy, changeRequest.NewPassword, user) {
+		return
+	}
+
+	if err = h.UserRepo.UpdatePassword(user, changeRequest.NewPassword); err != nil {
+		log.Printf("Error during ChangePassword.UpdatePassword: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	if err = h.Tokens.RevokeAllSessions(user.ID); err != nil {
+		log.Printf("Error during ChangePassword.RevokeAllSessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditPasswordChanged, "")
+	tokens, err := h.Tokens.StartSession(user, clientInfo(c))
+	if err != nil {
+		log.Printf("Error during ChangePassword.StartSession: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	err = h.Notifier.Notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Password changed",
+		Body:    fmt.Sprintf("The password of %s was changed, all other sessions were logged out.", user.Username),
+	})
+	if err != nil {
+		// the password is already changed, so the request doesn't fail because of the notification
+		log.Printf("Error during ChangePassword.Notify: %v", err)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// rejectWeakPassword responds with 400 and the broken rules if the password doesn't meet the policy.
+func rejectWeakPassword(c *gin.Context, policy *passwords.Policy, password string, user *models.User) bool {
+	violations := policy.Check(password, user.Username, user.Email)
+	if len(violations) == 0 {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, contracts.PasswordPolicyErrorResponse{
+		Error:      "Password doesn't meet the password policy",
+		Violations: violations,
+	})
+	return true
+}
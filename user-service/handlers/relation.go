@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RelationHandler struct {
+	UserRepo     *repositories.UserRepository
+	RelationRepo *repositories.RelationRepository
+	FollowRepo   *repositories.FollowRepository
+}
+
+func NewRelationHandler(
+	userRepo *repositories.UserRepository,
+	relationRepo *repositories.RelationRepository,
+	followRepo *repositories.FollowRepository) *RelationHandler {
+	return &RelationHandler{
+		UserRepo:     userRepo,
+		RelationRepo: relationRepo,
+		FollowRepo:   followRepo,
+	}
+}
+
+func (h *RelationHandler) Block(c *gin.Context) {
+	h.changeRelation(c, "Block", h.RelationRepo.Block, "User blocked")
+}
+
+func (h *RelationHandler) Unblock(c *gin.Context) {
+	h.changeRelation(c, "Unblock", h.RelationRepo.Unblock, "User unblocked")
+}
+
+func (h *RelationHandler) Mute(c *gin.Context) {
+	h.changeRelation(c, "Mute", h.RelationRepo.Mute, "User muted")
+}
+
+func (h *RelationHandler) Unmute(c *gin.Context) {
+	h.changeRelation(c, "Unmute", h.RelationRepo.Unmute, "User unmuted")
+}
+
+func (h *RelationHandler) ListBlocked(c *gin.Context) {
+	h.listUsers(c, "ListBlocked", h.RelationRepo.ListBlocked)
+}
+
+func (h *RelationHandler) ListMuted(c *gin.Context) {
+	h.listUsers(c, "ListMuted", h.RelationRepo.ListMuted)
+}
+
+func (h *RelationHandler) changeRelation(
+	c *gin.Context,
+	method string,
+	change func(userID, targetID uint) error,
+	message string) {
+	target := findPathUser(c, h.UserRepo, method)
+	if target == nil {
+		return
+	}
+	userID := c.GetUint("userID")
+	if target.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't do this to yourself"})
+		return
+	}
+
+	if err := change(userID, target.ID); err != nil {
+		log.Printf("Error during %s: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user relation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func (h *RelationHandler) listUsers(
+	c *gin.Context,
+	method string,
+	list func(userID uint, page, pageSize int) ([]models.User, int64, error)) {
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetUint("userID")
+	users, totalCount, err := list(userID, page, pageSize)
+	if err != nil {
+		log.Printf("Error during %s: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	profiles, err := publicProfiles(h.FollowRepo, users, userID)
+	if err != nil {
+		log.Printf("Error during %s.publicProfiles: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.ListUsersResponse{
+		Users:      profiles,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
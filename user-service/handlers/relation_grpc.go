@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"social-network/common/proto"
+	"social-network/user-service/repositories"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RelationGRPCHandler lets other services hide content of blocked, muted and banned users.
+type RelationGRPCHandler struct {
+	repo           *repositories.RelationRepository
+	moderationRepo *repositories.ModerationRepository
+	proto.UnimplementedUserRelationServiceServer
+}
+
+func NewRelationGRPCHandler(
+	repo *repositories.RelationRepository, moderationRepo *repositories.ModerationRepository) *RelationGRPCHandler {
+	return &RelationGRPCHandler{repo: repo, moderationRepo: moderationRepo}
+}
+
+func (h *RelationGRPCHandler) GetRelations(ctx context.Context, req *proto.GetRelationsRequest) (*proto.UserRelations, error) {
+	blocked, blockedBy, muted, err := h.repo.GetRelations(uint(req.UserId))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get relations: %v", err)
+	}
+	return &proto.UserRelations{
+		BlockedIds:   toUint64s(blocked),
+		BlockedByIds: toUint64s(blockedBy),
+		MutedIds:     toUint64s(muted),
+	}, nil
+}
+
+// ListBannedUsers returns every banned user, callers cache the list and share it between all viewers.
+func (h *RelationGRPCHandler) ListBannedUsers(ctx context.Context, req *proto.ListBannedUsersRequest) (*proto.BannedUsers, error) {
+	banned, err := h.moderationRepo.ListBannedIDs()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get banned users: %v", err)
+	}
+	return &proto.BannedUsers{UserIds: toUint64s(banned)}, nil
+}
+
+func toUint64s(ids []uint) []uint64 {
+	result := make([]uint64, len(ids))
+	for i, id := range ids {
+		result[i] = uint64(id)
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	UserRepo *repositories.UserRepository
+	RoleRepo *repositories.RoleRepository
+}
+
+func NewRoleHandler(userRepo *repositories.UserRepository, roleRepo *repositories.RoleRepository) *RoleHandler {
+	return &RoleHandler{
+		UserRepo: userRepo,
+		RoleRepo: roleRepo,
+	}
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.RoleRepo.ListRoles()
+	if err != nil {
+		log.Printf("Error during ListRoles.ListRoles: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *RoleHandler) GrantRole(c *gin.Context) {
+	var grantRequest contracts.GrantRoleRequest
+	if err := c.ShouldBindJSON(&grantRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, role, ok := h.findUserAndRole(c, grantRequest.Role)
+	if !ok {
+		return
+	}
+
+	if err := h.RoleRepo.AssignRole(user, role); err != nil {
+		log.Printf("Error during GrantRole.AssignRole: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant role"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	user, role, ok := h.findUserAndRole(c, c.Param("role"))
+	if !ok {
+		return
+	}
+
+	if role.Name == models.RoleAdmin && user.ID == c.GetUint("userID") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't revoke your own admin role"})
+		return
+	}
+
+	if err := h.RoleRepo.RemoveRole(user, role); err != nil {
+		log.Printf("Error during RevokeRole.RemoveRole: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke role"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *RoleHandler) findUserAndRole(c *gin.Context, roleName string) (*models.User, *models.Role, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return nil, nil, false
+	}
+
+	user, err := h.UserRepo.FindByID(uint(userID))
+	if err != nil {
+		log.Printf("Error during findUserAndRole.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return nil, nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, nil, false
+	}
+
+	role, err := h.RoleRepo.FindByName(roleName)
+	if err != nil {
+		log.Printf("Error during findUserAndRole.FindByName: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding role"})
+		return nil, nil, false
+	}
+	if role == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return nil, nil, false
+	}
+	return user, role, true
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	Tokens       *auth.TokenManager
+	AccessTokens *auth.AccessTokenManager
+	UserRepo     *repositories.UserRepository
+	AuditRepo    *repositories.AuditRepository
+}
+
+func NewSessionHandler(
+	tokens *auth.TokenManager,
+	accessTokens *auth.AccessTokenManager,
+	userRepo *repositories.UserRepository,
+	auditRepo *repositories.AuditRepository) *SessionHandler {
+	return &SessionHandler{
+		Tokens:       tokens,
+		AccessTokens: accessTokens,
+		UserRepo:     userRepo,
+		AuditRepo:    auditRepo,
+	}
+}
+
+func (h *SessionHandler) Refresh(c *gin.Context) {
+	var refreshRequest contracts.RefreshRequest
+	if err := c.ShouldBindJSON(&refreshRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.Tokens.Refresh(refreshRequest.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		log.Printf("Refresh token reuse detected, session revoked")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used, session revoked"})
+		return
+	case errors.Is(err, auth.ErrInvalidRefreshToken), errors.Is(err, auth.ErrSessionRevoked):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	case err != nil:
+		log.Printf("Error during Refresh.Refresh: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *SessionHandler) Logout(c *gin.Context) {
+	var logoutRequest contracts.LogoutRequest
+	if err := c.ShouldBindJSON(&logoutRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.Tokens.Revoke(logoutRequest.RefreshToken)
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during Logout.Revoke: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, session.UserID, models.AuditSessionRevoked, fmt.Sprintf("session %d", session.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// ListSessions returns the devices the user is logged in on, the current one is marked.
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.Tokens.ListSessions(c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during ListSessions.ListSessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+	response := contracts.ListSessionsResponse{Sessions: make([]contracts.Session, len(sessions))}
+	for i := range sessions {
+		response.Sessions[i] = contracts.NewSession(&sessions[i], c.GetUint("sessionID"))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession logs one device out, access tokens of the session stop working at once.
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+		return
+	}
+
+	session, err := h.Tokens.RevokeSession(c.GetUint("userID"), uint(sessionID))
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during RevokeSession.RevokeSession: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, session.UserID, models.AuditSessionRevoked, fmt.Sprintf("session %d", session.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions logs out every device except the one making the request.
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.GetUint("userID")
+	revokedCount, err := h.Tokens.RevokeOtherSessions(userID, c.GetUint("sessionID"))
+	if err != nil {
+		log.Printf("Error during RevokeOtherSessions.RevokeOtherSessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	if revokedCount > 0 {
+		recordAuditEvent(c, h.AuditRepo, userID, models.AuditSessionRevoked,
+			fmt.Sprintf("%d other sessions", revokedCount))
+	}
+
+	c.JSON(http.StatusOK, contracts.RevokeOtherSessionsResponse{RevokedCount: revokedCount})
+}
+
+// introspectToken checks a token for ValidateToken, invalid tokens are reported as inactive, not as errors.
+// It also reports the current account state, which may have changed since the token was issued.
+func introspectToken(
+	tokens *auth.TokenManager,
+	accessTokens *auth.AccessTokenManager,
+	userRepo *repositories.UserRepository,
+	token string) (contracts.IntrospectResponse, error) {
+	if auth.IsAccessToken(token) {
+		return introspectAccessToken(accessTokens, userRepo, token)
+	}
+
+	claims, err := tokens.ParseAccessToken(token)
+	if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrSessionRevoked) || errors.Is(err, auth.ErrTokenOutdated) {
+		return contracts.IntrospectResponse{Active: false}, nil
+	}
+	if err != nil {
+		return contracts.IntrospectResponse{}, err
+	}
+
+	user, err := userRepo.FindByID(claims.UserID)
+	if err != nil || user == nil {
+		return contracts.IntrospectResponse{Active: false}, err
+	}
+
+	return contracts.IntrospectResponse{
+		Active:        true,
+		TokenType:     contracts.TokenTypeAccess,
+		UserID:        claims.UserID,
+		Username:      claims.Username,
+		SessionID:     claims.SessionID,
+		Roles:         user.RoleNames(),
+		EmailVerified: user.EmailVerified,
+		TokenVersion:  user.TokenVersion,
+		Restricted:    user.IsRestricted(time.Now()),
+	}, nil
+}
+
+// introspectAccessToken reports no roles, personal access tokens can't be used for admin actions.
+func introspectAccessToken(
+	accessTokens *auth.AccessTokenManager,
+	userRepo *repositories.UserRepository,
+	token string) (contracts.IntrospectResponse, error) {
+	accessToken, err := accessTokens.Authenticate(token)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		return contracts.IntrospectResponse{Active: false}, nil
+	}
+	if err != nil {
+		return contracts.IntrospectResponse{}, err
+	}
+
+	user, err := userRepo.FindByID(accessToken.UserID)
+	if err != nil || user == nil {
+		return contracts.IntrospectResponse{Active: false}, err
+	}
+
+	return contracts.IntrospectResponse{
+		Active:        true,
+		TokenType:     contracts.TokenTypePersonalAccess,
+		UserID:        user.ID,
+		Username:      user.Username,
+		EmailVerified: user.EmailVerified,
+		TokenVersion:  user.TokenVersion,
+		Restricted:    user.IsRestricted(time.Now()),
+		Scopes:        accessToken.ScopeList(),
+	}, nil
+}
+
+// clientInfo describes the client of the request for the session it starts.
+func clientInfo(c *gin.Context) auth.ClientInfo {
+	return auth.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
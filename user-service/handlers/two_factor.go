@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	UserRepo   *repositories.UserRepository
+	TwoFactor  *auth.TwoFactorManager
+	Tokens     *auth.TokenManager
+	LoginGuard *auth.LoginGuard
+	AuditRepo  *repositories.AuditRepository
+}
+
+func NewTwoFactorHandler(
+	userRepo *repositories.UserRepository,
+	twoFactor *auth.TwoFactorManager,
+	tokens *auth.TokenManager,
+	loginGuard *auth.LoginGuard,
+	auditRepo *repositories.AuditRepository) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		UserRepo:   userRepo,
+		TwoFactor:  twoFactor,
+		Tokens:     tokens,
+		LoginGuard: loginGuard,
+		AuditRepo:  auditRepo,
+	}
+}
+
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	user := h.currentUser(c, "Enroll")
+	if user == nil {
+		return
+	}
+
+	secret, uri, err := h.TwoFactor.Enroll(user)
+	if errors.Is(err, auth.ErrTwoFactorAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during Enroll.Enroll: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contracts.EnrollTwoFactorResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	})
+}
+
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var codeRequest contracts.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&codeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := h.currentUser(c, "Confirm")
+	if user == nil {
+		return
+	}
+
+	codes, err := h.TwoFactor.Confirm(user, codeRequest.Code)
+	if err != nil {
+		h.respondTwoFactorError(c, "Confirm", err)
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditTwoFactorEnabled, "")
+
+	c.JSON(http.StatusOK, contracts.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var codeRequest contracts.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&codeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := h.currentUser(c, "Disable")
+	if user == nil {
+		return
+	}
+
+	if err := h.TwoFactor.Disable(user, codeRequest.Code); err != nil {
+		h.respondTwoFactorError(c, "Disable", err)
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditTwoFactorDisabled, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var codeRequest contracts.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&codeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := h.currentUser(c, "RegenerateRecoveryCodes")
+	if user == nil {
+		return
+	}
+
+	codes, err := h.TwoFactor.RegenerateRecoveryCodes(user, codeRequest.Code)
+	if err != nil {
+		h.respondTwoFactorError(c, "RegenerateRecoveryCodes", err)
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditRecoveryCodesRegenerated, "")
+
+	c.JSON(http.StatusOK, contracts.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// LoginTwoFactor is the second login step, it exchanges the challenge issued by Login for tokens.
+func (h *TwoFactorHandler) LoginTwoFactor(c *gin.Context) {
+	var loginRequest contracts.LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&loginRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.TwoFactor.CompleteChallenge(loginRequest.ChallengeToken, loginRequest.Code)
+	if errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+		err = h.LoginGuard.RecordFailure(user.Username, user, c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			log.Printf("Error during LoginTwoFactor.RecordFailure: %v", err)
+		}
+		recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditLoginFailed, "invalid two-factor code")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidChallenge) || errors.Is(err, auth.ErrTwoFactorNotEnabled) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error during LoginTwoFactor.CompleteChallenge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor code"})
+		return
+	}
+
+	// the account may have been locked by other attempts while the challenge was pending
+	retryAfter, err := h.LoginGuard.Check(user.Username, c.ClientIP())
+	if err != nil {
+		log.Printf("Error during LoginTwoFactor.Check: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking login attempts"})
+		return
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return
+	}
+
+	if rejectRestricted(c, user) {
+		return
+	}
+	if err = h.LoginGuard.RecordSuccess(user.Username); err != nil {
+		log.Printf("Error during LoginTwoFactor.RecordSuccess: %v", err)
+	}
+
+	tokens, err := h.Tokens.StartSession(user, clientInfo(c))
+	if err != nil {
+		log.Printf("Error during LoginTwoFactor.StartSession: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate jwtToken"})
+		return
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditLoginSucceeded, "with two-factor code")
+
+	c.JSON(http.StatusOK, contracts.AuthResponse{
+		TokenPair: *tokens,
+		User:      *user,
+	})
+}
+
+func (h *TwoFactorHandler) currentUser(c *gin.Context, method string) *models.User {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil
+	}
+	user, err := h.UserRepo.FindByID(userID.(uint))
+	if err != nil {
+		log.Printf("Error during %s.FindByID: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return nil
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil
+	}
+	return user
+}
+
+func (h *TwoFactorHandler) respondTwoFactorError(c *gin.Context, method string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor code"})
+	case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+	case errors.Is(err, auth.ErrTwoFactorNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor enrollment first"})
+	default:
+		log.Printf("Error during %s: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update two-factor authentication"})
+	}
+}
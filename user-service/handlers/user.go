@@ -1,28 +1,63 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
+	"social-network/user-service/accounts"
+	"social-network/user-service/auth"
 	"social-network/user-service/contracts"
 	"social-network/user-service/models"
+	"social-network/user-service/passwords"
 	"social-network/user-service/repositories"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	UserRepo *repositories.UserRepository
-	JwtKey   []byte
+	UserRepo      *repositories.UserRepository
+	RoleRepo      *repositories.RoleRepository
+	Tokens        *auth.TokenManager
+	EmailVerifier *auth.EmailVerifier
+	LoginGuard    *auth.LoginGuard
+	TwoFactor     *auth.TwoFactorManager
+	FollowRepo    *repositories.FollowRepository
+	RelationRepo  *repositories.RelationRepository
+	AuditRepo     *repositories.AuditRepository
+	Policy        *passwords.Policy
+	Inviter       *accounts.Inviter
 }
 
-func NewUserHandler(userRepo *repositories.UserRepository, jwtKey string) *UserHandler {
+func NewUserHandler(
+	userRepo *repositories.UserRepository,
+	roleRepo *repositories.RoleRepository,
+	tokens *auth.TokenManager,
+	emailVerifier *auth.EmailVerifier,
+	loginGuard *auth.LoginGuard,
+	twoFactor *auth.TwoFactorManager,
+	followRepo *repositories.FollowRepository,
+	relationRepo *repositories.RelationRepository,
+	auditRepo *repositories.AuditRepository,
+	policy *passwords.Policy,
+	inviter *accounts.Inviter) *UserHandler {
 	return &UserHandler{
-		UserRepo: userRepo,
-		JwtKey:   []byte(jwtKey),
+		UserRepo:      userRepo,
+		RoleRepo:      roleRepo,
+		Tokens:        tokens,
+		EmailVerifier: emailVerifier,
+		LoginGuard:    loginGuard,
+		TwoFactor:     twoFactor,
+		FollowRepo:    followRepo,
+		RelationRepo:  relationRepo,
+		AuditRepo:     auditRepo,
+		Policy:        policy,
+		Inviter:       inviter,
 	}
 }
 
@@ -64,25 +99,77 @@ func (h *UserHandler) Register(c *gin.Context) {
 		BirthDate:   registerRequest.BirthDate,
 		PhoneNumber: registerRequest.PhoneNumber,
 	}
+	if rejectWeakPassword(c, h.Policy, registerRequest.Password, &user) {
+		return
+	}
+
+	// redeemed last, so a registration rejected for another reason doesn't use up the code
+	invite, err := h.Inviter.Redeem(registerRequest.InviteCode)
+	switch {
+	case errors.Is(err, accounts.ErrInviteRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration requires an invite code"})
+		return
+	case errors.Is(err, accounts.ErrInvalidInvite):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired invite code"})
+		return
+	case err != nil:
+		log.Printf("Error during Register.Redeem: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking invite code"})
+		return
+	}
+	if invite != nil {
+		user.InvitedByID = &invite.CreatedByID
+		user.InviteCodeID = &invite.ID
+	}
 
 	err = h.UserRepo.CreateUser(&user)
 	if err != nil {
 		log.Printf("Error during Register.CreateUser: %v", err)
+		if invite != nil {
+			if err = h.Inviter.Release(invite); err != nil {
+				log.Printf("Error during Register.Release: %v", err)
+			}
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 	fmt.Println(user.ID)
 
-	jwtToken, err := h.generateJwtToken(user.ID, user.Username)
+	defaultRole, err := h.RoleRepo.FindByName(models.RoleUser)
+	if err != nil {
+		log.Printf("Error during Register.FindByName: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign role"})
+		return
+	}
+	if defaultRole != nil {
+		if err = h.RoleRepo.AssignRole(&user, defaultRole); err != nil {
+			log.Printf("Error during Register.AssignRole: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign role"})
+			return
+		}
+	}
+
+	registeredDetails := ""
+	if invite != nil {
+		registeredDetails = fmt.Sprintf("invited by user %d", invite.CreatedByID)
+	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditRegistered, registeredDetails)
+
+	// the user can ask for another token later, so a delivery failure doesn't fail the registration
+	if err = h.EmailVerifier.SendVerification(&user); err != nil {
+		log.Printf("Error during Register.SendVerification: %v", err)
+	}
+
+	tokens, err := h.Tokens.StartSession(&user, clientInfo(c))
 	if err != nil {
-		log.Printf("Error during Register.generateJwtToken: %v", err)
+		log.Printf("Error during Register.StartSession: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate jwtToken"})
 		return
 	}
 
 	c.JSON(http.StatusCreated, contracts.AuthResponse{
-		JwtToken: jwtToken,
-		User:     user,
+		TokenPair: *tokens,
+		User:      user,
 	})
 }
 
@@ -93,33 +180,87 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	retryAfter, err := h.LoginGuard.Check(loginRequest.Username, c.ClientIP())
+	if err != nil {
+		log.Printf("Error during Login.Check: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking login attempts"})
+		return
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return
+	}
+
 	user, err := h.UserRepo.FindByUsername(loginRequest.Username)
 	if err != nil {
 		log.Printf("Error during Login.FindByUsername: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
 		return
 	}
-	if user == nil {
+	passwordValid, needsRehash := false, false
+	if user != nil {
+		passwordValid, needsRehash, err = h.UserRepo.CheckPassword(user, loginRequest.Password)
+		if err != nil {
+			log.Printf("Error during Login.CheckPassword: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking password"})
+			return
+		}
+	}
+	if !passwordValid {
+		err = h.LoginGuard.RecordFailure(loginRequest.Username, user, c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			log.Printf("Error during Login.RecordFailure: %v", err)
+		}
+		// unknown usernames have no audit log, the login guard still keeps the attempt
+		if user != nil {
+			recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditLoginFailed, "invalid credentials")
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+	// restricted accounts neither clear their failures nor get their hash upgraded
+	if rejectRestricted(c, user) {
+		return
+	}
+	// the plain password is only known here, so legacy hashes are upgraded on a successful login
+	if needsRehash {
+		if err = h.UserRepo.RehashPassword(user, loginRequest.Password); err != nil {
+			log.Printf("Error during Login.RehashPassword: %v", err)
+		}
+	}
+
+	if user.TwoFactorEnabled {
+		challengeToken, expiresAt, err := h.TwoFactor.CreateChallenge(user)
+		if err != nil {
+			log.Printf("Error during Login.CreateChallenge: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create login challenge"})
+			return
+		}
+		c.JSON(http.StatusOK, contracts.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresAt:         expiresAt,
+		})
 		return
 	}
 
-	jwtToken, err := h.generateJwtToken(user.ID, user.Username)
+	// with 2FA the failures are cleared by LoginTwoFactor, so the password alone doesn't reset the lockout of the code
+	if err = h.LoginGuard.RecordSuccess(user.Username); err != nil {
+		log.Printf("Error during Login.RecordSuccess: %v", err)
+	}
+	tokens, err := h.Tokens.StartSession(user, clientInfo(c))
 	if err != nil {
-		log.Printf("Error during Login.generateJwtToken: %v", err)
+		log.Printf("Error during Login.StartSession: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate jwtToken"})
 		return
 	}
+	recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditLoginSucceeded, "")
 
 	c.JSON(http.StatusOK, contracts.AuthResponse{
-		JwtToken: jwtToken,
-		User:     *user,
+		TokenPair: *tokens,
+		User:      *user,
 	})
 }
 
@@ -145,6 +286,128 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// GetUser returns the public profile of any user.
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(uint(id))
+	if err != nil {
+		log.Printf("Error during GetUser.FindByID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	h.respondPublicProfile(c, user)
+}
+
+func (h *UserHandler) GetUserByUsername(c *gin.Context) {
+	user, err := h.UserRepo.FindByUsername(c.Param("username"))
+	if err != nil {
+		log.Printf("Error during GetUserByUsername.FindByUsername: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	h.respondPublicProfile(c, user)
+}
+
+func (h *UserHandler) SearchUsers(c *gin.Context) {
+	search := strings.TrimSpace(c.Query("q"))
+	if search == "" || len(search) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "search query is not provided or invalid"})
+		return
+	}
+	page, pageSize, ok := paginationFromQuery(c)
+	if !ok {
+		return
+	}
+
+	users, totalCount, err := h.UserRepo.SearchUsers(search, c.GetUint("userID"), page, pageSize)
+	if err != nil {
+		log.Printf("Error during SearchUsers.SearchUsers: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+
+	profiles, err := publicProfiles(h.FollowRepo, users, c.GetUint("userID"))
+	if err != nil {
+		log.Printf("Error during SearchUsers.publicProfiles: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	c.JSON(http.StatusOK, contracts.ListUsersResponse{
+		Users:      profiles,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+func (h *UserHandler) respondPublicProfile(c *gin.Context, user *models.User) {
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	viewerID := c.GetUint("userID")
+
+	// users who blocked the viewer look as if they didn't exist
+	blocked, err := h.RelationRepo.IsBlocking(user.ID, viewerID)
+	if err != nil {
+		log.Printf("Error during respondPublicProfile.IsBlocking: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding user"})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	followersCount, err := h.FollowRepo.CountFollowers(user.ID)
+	if err != nil {
+		log.Printf("Error during respondPublicProfile.CountFollowers: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting followers"})
+		return
+	}
+	followingCount, err := h.FollowRepo.CountFollowing(user.ID)
+	if err != nil {
+		log.Printf("Error during respondPublicProfile.CountFollowing: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting followers"})
+		return
+	}
+	isFollowed, err := h.FollowRepo.IsFollowing(viewerID, user.ID)
+	if err != nil {
+		log.Printf("Error during respondPublicProfile.IsFollowing: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking follow"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contracts.UserProfileResponse{
+		PublicProfile:  contracts.NewPublicProfile(user, viewerID, isFollowed),
+		FollowersCount: followersCount,
+		FollowingCount: followingCount,
+		IsFollowed:     isFollowed,
+	})
+}
+
+// publicProfiles projects users for the viewer, it finds which of them the viewer follows with one query.
+func publicProfiles(followRepo *repositories.FollowRepository, users []models.User, viewerID uint) ([]contracts.PublicProfile, error) {
+	ids := make([]uint, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	followed, err := followRepo.FollowedAmong(viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]contracts.PublicProfile, len(users))
+	for i := range users {
+		profiles[i] = contracts.NewPublicProfile(&users[i], viewerID, followed[users[i].ID])
+	}
+	return profiles, nil
+}
+
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -157,6 +420,17 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	cleared, conflict := clearedProfileFields(&updateRequest)
+	if conflict != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Field %s can't be both set and cleared", conflict)})
+		return
+	}
+	for _, link := range append([]string{updateRequest.Website}, updateRequest.Links...) {
+		if link != "" && !isProfileURL(link) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid link %q, only http and https URLs are allowed", link)})
+			return
+		}
+	}
 
 	user, err := h.UserRepo.FindByID(userID.(uint))
 	if err != nil {
@@ -169,12 +443,24 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	if updateRequest.FirstName != "" {
-		user.FirstName = updateRequest.FirstName
-	}
-	if updateRequest.LastName != "" {
-		user.LastName = updateRequest.LastName
+	var changedFields []string
+	// setField applies a text field unless it is empty, cleared fields are emptied
+	setField := func(field, value string, target *string) {
+		if cleared[field] {
+			value = ""
+		} else if value == "" {
+			return
+		}
+		if value != *target {
+			*target = value
+			changedFields = append(changedFields, field)
+		}
 	}
+	setField("first_name", updateRequest.FirstName, &user.FirstName)
+	setField("last_name", updateRequest.LastName, &user.LastName)
+	setField("display_name", updateRequest.DisplayName, &user.DisplayName)
+	previousEmail := user.Email
+	emailChanged := false
 	if updateRequest.Email != "" && updateRequest.Email != user.Email {
 		existingUser, err := h.UserRepo.FindByEmail(updateRequest.Email)
 		if err != nil {
@@ -187,13 +473,30 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 			return
 		}
 		user.Email = updateRequest.Email
+		user.EmailVerified = false
+		emailChanged = true
 	}
-	if updateRequest.BirthDate != nil {
+	if cleared["birth_date"] && user.BirthDate != nil {
+		user.BirthDate = nil
+		changedFields = append(changedFields, "birth_date")
+	} else if updateRequest.BirthDate != nil && (user.BirthDate == nil || !updateRequest.BirthDate.Equal(*user.BirthDate)) {
 		user.BirthDate = updateRequest.BirthDate
+		changedFields = append(changedFields, "birth_date")
 	}
-	if updateRequest.PhoneNumber != "" {
-		user.PhoneNumber = updateRequest.PhoneNumber
+	setField("phone_number", updateRequest.PhoneNumber, &user.PhoneNumber)
+	setField("bio", updateRequest.Bio, &user.Bio)
+	setField("location", updateRequest.Location, &user.Location)
+	setField("website", updateRequest.Website, &user.Website)
+	if cleared["links"] && len(user.Links) > 0 {
+		user.Links = nil
+		changedFields = append(changedFields, "links")
+	} else if len(updateRequest.Links) > 0 && !slices.Equal(updateRequest.Links, user.Links) {
+		user.Links = updateRequest.Links
+		changedFields = append(changedFields, "links")
 	}
+	setField("email_visibility", updateRequest.EmailVisibility, &user.EmailVisibility)
+	setField("phone_number_visibility", updateRequest.PhoneNumberVisibility, &user.PhoneNumberVisibility)
+	setField("birth_date_visibility", updateRequest.BirthDateVisibility, &user.BirthDateVisibility)
 
 	if err = h.UserRepo.UpdateUser(user); err != nil {
 		log.Printf("Error during UpdateProfile.UpdateUser: %v", err)
@@ -201,19 +504,46 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if len(changedFields) > 0 {
+		recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditProfileUpdated, strings.Join(changedFields, ", "))
+	}
+	if emailChanged {
+		recordAuditEvent(c, h.AuditRepo, user.ID, models.AuditEmailChanged,
+			fmt.Sprintf("%s -> %s", previousEmail, user.Email))
+		if err = h.EmailVerifier.SendVerification(user); err != nil {
+			log.Printf("Error during UpdateProfile.SendVerification: %v", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
-func (h *UserHandler) generateJwtToken(userID uint, username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  userID,
-		"username": username,
-		"exp":      expirationTime.Unix(),
-	})
-
-	tokenString, err := token.SignedString(h.JwtKey)
+// clearedProfileFields collects the fields the request empties.
+// conflict names a field which is both set and cleared, such a request is ambiguous.
+func clearedProfileFields(request *contracts.UpdateProfileRequest) (cleared map[string]bool, conflict string) {
+	set := map[string]bool{
+		"first_name":   request.FirstName != "",
+		"last_name":    request.LastName != "",
+		"display_name": request.DisplayName != "",
+		"bio":          request.Bio != "",
+		"location":     request.Location != "",
+		"website":      request.Website != "",
+		"links":        len(request.Links) > 0,
+		"birth_date":   request.BirthDate != nil,
+		"phone_number": request.PhoneNumber != "",
+	}
+	cleared = make(map[string]bool, len(request.Clear))
+	for _, field := range request.Clear {
+		if set[field] {
+			return nil, field
+		}
+		cleared[field] = true
+	}
+	return cleared, ""
+}
 
-	return tokenString, err
+// isProfileURL accepts absolute http and https URLs only, other schemes like javascript: are unsafe to show as links.
+func isProfileURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
 }
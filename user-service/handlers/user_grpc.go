@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"social-network/common/proto"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const maxBatchGetUsers = 100
+
+// UserGRPCHandler lets other services resolve users and validate tokens.
+// Users who blocked the viewer are reported as missing, like in the HTTP API.
+type UserGRPCHandler struct {
+	userRepo     *repositories.UserRepository
+	relationRepo *repositories.RelationRepository
+	followRepo   *repositories.FollowRepository
+	tokens       *auth.TokenManager
+	accessTokens *auth.AccessTokenManager
+	proto.UnimplementedUserServiceServer
+}
+
+func NewUserGRPCHandler(
+	userRepo *repositories.UserRepository,
+	relationRepo *repositories.RelationRepository,
+	followRepo *repositories.FollowRepository,
+	tokens *auth.TokenManager,
+	accessTokens *auth.AccessTokenManager) *UserGRPCHandler {
+	return &UserGRPCHandler{
+		userRepo:     userRepo,
+		relationRepo: relationRepo,
+		followRepo:   followRepo,
+		tokens:       tokens,
+		accessTokens: accessTokens,
+	}
+}
+
+func (h *UserGRPCHandler) GetUser(ctx context.Context, req *proto.GetUserRequest) (*proto.User, error) {
+	user, err := h.userRepo.FindByID(uint(req.Id))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get user: %v", err)
+	}
+	return h.visibleUser(user, uint(req.ViewerId))
+}
+
+func (h *UserGRPCHandler) GetUserByUsername(ctx context.Context, req *proto.GetUserByUsernameRequest) (*proto.User, error) {
+	if req.Username == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Username is required")
+	}
+	user, err := h.userRepo.FindByUsername(req.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get user: %v", err)
+	}
+	return h.visibleUser(user, uint(req.ViewerId))
+}
+
+func (h *UserGRPCHandler) BatchGetUsers(ctx context.Context, req *proto.BatchGetUsersRequest) (*proto.BatchGetUsersResponse, error) {
+	if len(req.Ids) > maxBatchGetUsers {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d users can be requested at once", maxBatchGetUsers)
+	}
+	ids := make([]uint, len(req.Ids))
+	for i, id := range req.Ids {
+		ids[i] = uint(id)
+	}
+	users, err := h.userRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get users: %v", err)
+	}
+
+	viewerID := uint(req.ViewerId)
+	hidden := make(map[uint]bool)
+	if viewerID != 0 {
+		_, blockedBy, _, err := h.relationRepo.GetRelations(viewerID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to get relations: %v", err)
+		}
+		for _, id := range blockedBy {
+			hidden[id] = true
+		}
+	}
+
+	visible := make([]models.User, 0, len(users))
+	for i := range users {
+		if !hidden[users[i].ID] {
+			visible = append(visible, users[i])
+		}
+	}
+	profiles, err := publicProfiles(h.followRepo, visible, viewerID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to check follows: %v", err)
+	}
+
+	response := &proto.BatchGetUsersResponse{Users: make([]*proto.User, len(profiles))}
+	for i := range profiles {
+		response.Users[i] = profileToProto(profiles[i])
+	}
+	return response, nil
+}
+
+func (h *UserGRPCHandler) ValidateToken(ctx context.Context, req *proto.ValidateTokenRequest) (*proto.ValidateTokenResponse, error) {
+	introspection, err := introspectToken(h.tokens, h.accessTokens, h.userRepo, req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to validate token: %v", err)
+	}
+	return &proto.ValidateTokenResponse{
+		Active:        introspection.Active,
+		TokenType:     introspection.TokenType,
+		UserId:        uint64(introspection.UserID),
+		Username:      introspection.Username,
+		SessionId:     uint64(introspection.SessionID),
+		Roles:         introspection.Roles,
+		EmailVerified: introspection.EmailVerified,
+		TokenVersion:  uint64(introspection.TokenVersion),
+		Scopes:        introspection.Scopes,
+		Restricted:    introspection.Restricted,
+	}, nil
+}
+
+func (h *UserGRPCHandler) visibleUser(user *models.User, viewerID uint) (*proto.User, error) {
+	if user == nil {
+		return nil, status.Errorf(codes.NotFound, "User not found")
+	}
+	follower := false
+	if viewerID != 0 {
+		blocked, err := h.relationRepo.IsBlocking(user.ID, viewerID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to check block: %v", err)
+		}
+		if blocked {
+			return nil, status.Errorf(codes.NotFound, "User not found")
+		}
+		if follower, err = h.followRepo.IsFollowing(viewerID, user.ID); err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to check follow: %v", err)
+		}
+	}
+	return profileToProto(contracts.NewPublicProfile(user, viewerID, follower)), nil
+}
+
+// profileToProto keeps the privacy rules of the public profile, viewerID 0 sees public fields only.
+func profileToProto(profile contracts.PublicProfile) *proto.User {
+	result := &proto.User{
+		Id:          uint64(profile.ID),
+		Username:    profile.Username,
+		FirstName:   profile.FirstName,
+		LastName:    profile.LastName,
+		Email:       profile.Email,
+		PhoneNumber: profile.PhoneNumber,
+		CreatedAt:   timestamppb.New(profile.CreatedAt),
+		DisplayName: profile.DisplayName,
+		AvatarUrls:  profile.AvatarURLs,
+	}
+	if profile.BirthDate != nil {
+		result.BirthDate = timestamppb.New(*profile.BirthDate)
+	}
+	return result
+}
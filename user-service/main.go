@@ -1,16 +1,33 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
+	"social-network/common/proto"
+	"social-network/user-service/accounts"
+	"social-network/user-service/auth"
+	"social-network/user-service/avatars"
 	"social-network/user-service/handlers"
 	"social-network/user-service/middleware"
 	"social-network/user-service/models"
+	"social-network/user-service/notifier"
+	"social-network/user-service/passwords"
 	"social-network/user-service/repositories"
+	"social-network/user-service/storage"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -32,31 +49,252 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	if err = db.AutoMigrate(&models.Role{}, &models.Permission{}); err != nil {
+		log.Fatalf("Failed to migrate table Role: %v", err)
+	}
 	if err = db.AutoMigrate(&models.User{}); err != nil {
 		log.Fatalf("Failed to migrate table User: %v", err)
 	}
+	if err = db.AutoMigrate(&models.Session{}, &models.RotatedRefreshToken{}); err != nil {
+		log.Fatalf("Failed to migrate table Session: %v", err)
+	}
+	if err = db.AutoMigrate(&models.PasswordResetToken{}, &models.EmailVerificationToken{}); err != nil {
+		log.Fatalf("Failed to migrate one-time token tables: %v", err)
+	}
+	if err = db.AutoMigrate(&models.LoginFailure{}, &models.AccountLockout{}); err != nil {
+		log.Fatalf("Failed to migrate login attempt tables: %v", err)
+	}
+	if err = db.AutoMigrate(&models.TwoFactor{}, &models.RecoveryCode{}, &models.LoginChallenge{}); err != nil {
+		log.Fatalf("Failed to migrate two-factor tables: %v", err)
+	}
+	if err = db.AutoMigrate(&models.Follow{}, &models.Block{}, &models.Mute{}); err != nil {
+		log.Fatalf("Failed to migrate user relation tables: %v", err)
+	}
+	if err = db.AutoMigrate(&models.PersonalAccessToken{}); err != nil {
+		log.Fatalf("Failed to migrate table PersonalAccessToken: %v", err)
+	}
+	if err = db.AutoMigrate(&models.AccountDeletion{}); err != nil {
+		log.Fatalf("Failed to migrate table AccountDeletion: %v", err)
+	}
+	if err = db.AutoMigrate(&models.ModerationAction{}); err != nil {
+		log.Fatalf("Failed to migrate table ModerationAction: %v", err)
+	}
+	if err = db.AutoMigrate(&models.AuditEvent{}); err != nil {
+		log.Fatalf("Failed to migrate table AuditEvent: %v", err)
+	}
+	if err = db.AutoMigrate(&models.InviteCode{}); err != nil {
+		log.Fatalf("Failed to migrate table InviteCode: %v", err)
+	}
+
+	userRepo := repositories.NewUserRepository(db, loadPasswordHasher())
+	sessionRepo := repositories.NewSessionRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	resetRepo := repositories.NewPasswordResetRepository(db)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	twoFactorRepo := repositories.NewTwoFactorRepository(db)
+	followRepo := repositories.NewFollowRepository(db)
+	relationRepo := repositories.NewRelationRepository(db)
+	accountDeletionRepo := repositories.NewAccountDeletionRepository(db)
+	dataExportRepo := repositories.NewDataExportRepository(db)
+	moderationRepo := repositories.NewModerationRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+
+	if err = userRepo.EnsureSearchIndexes(); err != nil {
+		log.Fatalf("Failed to create user search indexes: %v", err)
+	}
+	if err = roleRepo.EnsureRoles(models.DefaultRoles); err != nil {
+		log.Fatalf("Failed to create default roles: %v", err)
+	}
+	bootstrapAdmins(userRepo, roleRepo, os.Getenv("ADMIN_USERNAMES"))
+
+	accessTokenTTL := durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+	keyRing := loadKeyRing(accessTokenTTL)
+	if rotationInterval := durationFromEnv("JWT_KEY_ROTATION_INTERVAL", 0); rotationInterval > 0 {
+		go keyRing.RunRotation(context.Background(), rotationInterval)
+	}
 
-	userRepo := repositories.NewUserRepository(db)
+	tokens := auth.NewTokenManager(
+		keyRing,
+		userRepo,
+		sessionRepo,
+		accessTokenTTL,
+		durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour))
 
-	jwtKey := os.Getenv("JWT_SECRET")
-	if jwtKey == "" {
-		jwtKey = "default_secret_key" // DEV ONLY
+	var userNotifier notifier.Notifier = notifier.NewLogNotifier()
+	if notificationsFile := os.Getenv("NOTIFICATIONS_FILE"); notificationsFile != "" {
+		userNotifier = notifier.NewFileNotifier(notificationsFile)
 	}
+	emailVerifier := auth.NewEmailVerifier(
+		emailVerificationRepo, userNotifier, durationFromEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour))
 
-	userHandler := handlers.NewUserHandler(userRepo, jwtKey)
+	loginGuardConfig := auth.DefaultLoginGuardConfig()
+	loginGuardConfig.MaxAccountFailures = intFromEnv("LOGIN_MAX_FAILURES", loginGuardConfig.MaxAccountFailures)
+	loginGuardConfig.MaxIPFailures = intFromEnv("LOGIN_MAX_IP_FAILURES", loginGuardConfig.MaxIPFailures)
+	loginGuardConfig.LockoutDuration = durationFromEnv("LOGIN_LOCKOUT_DURATION", loginGuardConfig.LockoutDuration)
+	loginGuardConfig.FailureWindow = durationFromEnv("LOGIN_FAILURE_WINDOW", loginGuardConfig.FailureWindow)
+	loginGuard := auth.NewLoginGuard(loginAttemptRepo, loginGuardConfig)
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "social-network"
+	}
+	twoFactor := auth.NewTwoFactorManager(
+		twoFactorRepo, userRepo, totpIssuer, durationFromEnv("LOGIN_CHALLENGE_TTL", 5*time.Minute))
+
+	postServiceURL := os.Getenv("POST_SERVICE_GRPC_URL")
+	if postServiceURL == "" {
+		postServiceURL = "post-service:50051"
+	}
+	postConn, err := grpc.NewClient(postServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to post service: %v", err)
+	}
+	defer postConn.Close()
+	avatarsDir := os.Getenv("AVATARS_DIR")
+	if avatarsDir == "" {
+		avatarsDir = filepath.Join(os.TempDir(), "avatars")
+	}
+	avatarService := avatars.NewService(
+		storage.NewLocalBlobStore(avatarsDir), int64(intFromEnv("AVATAR_MAX_BYTES", 5<<20)))
+
+	deleter := accounts.NewDeleter(
+		accountDeletionRepo,
+		proto.NewPostServiceClient(postConn),
+		avatarService,
+		durationFromEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		os.Getenv("ACCOUNT_DELETION_ANONYMIZE_POSTS") == "true")
+	go deleter.Run(context.Background(), durationFromEnv("ACCOUNT_DELETION_INTERVAL", time.Hour))
+
+	passwordPolicy := loadPasswordPolicy()
+	inviter := accounts.NewInviter(repositories.NewInviteRepository(db), registrationInviteOnly())
+	userHandler := handlers.NewUserHandler(
+		userRepo, roleRepo, tokens, emailVerifier, loginGuard, twoFactor, followRepo, relationRepo, auditRepo, passwordPolicy,
+		inviter)
+	twoFactorHandler := handlers.NewTwoFactorHandler(userRepo, twoFactor, tokens, loginGuard, auditRepo)
+	followHandler := handlers.NewFollowHandler(userRepo, followRepo, relationRepo)
+	relationHandler := handlers.NewRelationHandler(userRepo, relationRepo, followRepo)
+	accountDeletionHandler := handlers.NewAccountDeletionHandler(userRepo, deleter, auditRepo)
+	dataExportHandler := handlers.NewDataExportHandler(userRepo, dataExportRepo)
+	accessTokens := auth.NewAccessTokenManager(repositories.NewAccessTokenRepository(db))
+	accessTokenHandler := handlers.NewAccessTokenHandler(userRepo, accessTokens, auditRepo)
+	sessionHandler := handlers.NewSessionHandler(tokens, accessTokens, userRepo, auditRepo)
+	keysHandler := handlers.NewKeysHandler(keyRing)
+	avatarHandler := handlers.NewAvatarHandler(userRepo, avatarService)
+	moderationHandler := handlers.NewModerationHandler(
+		userRepo, moderationRepo, accounts.NewModerator(moderationRepo, tokens))
+	roleHandler := handlers.NewRoleHandler(userRepo, roleRepo)
+	emailHandler := handlers.NewEmailHandler(userRepo, emailVerifier)
+	lockoutHandler := handlers.NewLockoutHandler(userRepo, loginAttemptRepo, loginGuard)
+	passwordHandler := handlers.NewPasswordHandler(
+		userRepo, resetRepo, tokens, userNotifier, durationFromEnv("PASSWORD_RESET_TTL", time.Hour), auditRepo,
+		passwordPolicy)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	inviteHandler := handlers.NewInviteHandler(userRepo, inviter)
 
 	router := gin.Default()
 
+	router.GET("/.well-known/jwks.json", keysHandler.JWKS)
 	router.POST("/api/auth/register", userHandler.Register)
 	router.POST("/api/auth/login", userHandler.Login)
+	router.POST("/api/auth/login/2fa", twoFactorHandler.LoginTwoFactor)
+	router.POST("/api/auth/refresh", sessionHandler.Refresh)
+	router.POST("/api/auth/logout", sessionHandler.Logout)
+	router.POST("/api/auth/password/forgot", passwordHandler.ForgotPassword)
+	router.POST("/api/auth/password/reset", passwordHandler.ResetPassword)
+	router.POST("/api/auth/verify-email", emailHandler.VerifyEmail)
+	router.GET("/api/users/avatars/:userId/:file", avatarHandler.GetAvatar)
+
+	// the export holds all personal data of the user, so only services with the shared token may read it
+	internalToken := os.Getenv("INTERNAL_API_TOKEN")
+	if internalToken == "" {
+		log.Println("INTERNAL_API_TOKEN is not set, the user data export is disabled")
+	}
+	internalUsers := router.Group("/internal/users")
+	internalUsers.Use(middleware.RequireInternalToken(internalToken))
+	internalUsers.GET("/:id/export", dataExportHandler.ExportUserData)
+
+	users := router.Group("/api/users")
+	users.Use(middleware.AuthMiddleware(tokens))
+	{
+		users.GET("/profile", userHandler.GetProfile)
+		users.PUT("/profile", userHandler.UpdateProfile)
+		users.PUT("/profile/avatar", avatarHandler.UploadAvatar)
+		users.DELETE("/profile/avatar", avatarHandler.DeleteAvatar)
+		users.PUT("/password", passwordHandler.ChangePassword)
+		users.GET("/security-events", auditHandler.ListOwnEvents)
+		users.GET("/tokens", accessTokenHandler.ListTokens)
+		users.POST("/tokens", accessTokenHandler.CreateToken)
+		users.DELETE("/tokens/:tokenId", accessTokenHandler.RevokeToken)
+		users.GET("/invites", inviteHandler.ListInvites)
+		users.POST("/invites", inviteHandler.CreateInvite)
+		users.DELETE("/invites/:inviteId", inviteHandler.RevokeInvite)
+		users.GET("/sessions", sessionHandler.ListSessions)
+		users.DELETE("/sessions/others", sessionHandler.RevokeOtherSessions)
+		users.DELETE("/sessions/:sessionId", sessionHandler.RevokeSession)
+		users.DELETE("/profile", accountDeletionHandler.DeleteAccount)
+		users.GET("/profile/deletion", accountDeletionHandler.GetDeletion)
+		users.POST("/profile/deletion/cancel", accountDeletionHandler.CancelDeletion)
+		users.GET("/search", userHandler.SearchUsers)
+		users.GET("/:id", userHandler.GetUser)
+		users.GET("/by-username/:username", userHandler.GetUserByUsername)
+		users.POST("/:id/follow", followHandler.Follow)
+		users.DELETE("/:id/follow", followHandler.Unfollow)
+		users.GET("/:id/followers", followHandler.ListFollowers)
+		users.GET("/:id/following", followHandler.ListFollowing)
+		users.GET("/:id/following/:targetId", followHandler.IsFollowing)
+		users.GET("/blocks", relationHandler.ListBlocked)
+		users.POST("/:id/block", relationHandler.Block)
+		users.DELETE("/:id/block", relationHandler.Unblock)
+		users.GET("/mutes", relationHandler.ListMuted)
+		users.POST("/:id/mute", relationHandler.Mute)
+		users.DELETE("/:id/mute", relationHandler.Unmute)
+		users.POST("/verify-email/resend", emailHandler.ResendVerification)
+		users.POST("/2fa/enroll", twoFactorHandler.Enroll)
+		users.POST("/2fa/confirm", twoFactorHandler.Confirm)
+		users.POST("/2fa/disable", twoFactorHandler.Disable)
+		users.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+	}
 
-	auth := router.Group("/api/users")
-	auth.Use(middleware.AuthMiddleware(jwtKey))
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware(tokens))
 	{
-		auth.GET("/profile", userHandler.GetProfile)
-		auth.PUT("/profile", userHandler.UpdateProfile)
+		manageRoles := middleware.RequirePermission(roleRepo, models.PermissionManageRoles)
+		moderateUsers := middleware.RequirePermission(roleRepo, models.PermissionModerateUsers)
+		requireAdmin := middleware.RequireRoles(models.RoleAdmin)
+		admin.GET("/roles", manageRoles, roleHandler.ListRoles)
+		admin.POST("/users/:id/roles", manageRoles, roleHandler.GrantRole)
+		admin.DELETE("/users/:id/roles/:role", manageRoles, roleHandler.RevokeRole)
+		admin.POST("/users/:id/unlock", requireAdmin, lockoutHandler.UnlockUser)
+		admin.GET("/lockouts", requireAdmin, lockoutHandler.ListLockouts)
+		admin.POST("/users/:id/suspend", moderateUsers, moderationHandler.SuspendUser)
+		admin.POST("/users/:id/ban", moderateUsers, moderationHandler.BanUser)
+		admin.POST("/users/:id/reinstate", moderateUsers, moderationHandler.ReinstateUser)
+		admin.GET("/users/:id/moderation", moderateUsers, moderationHandler.GetModeration)
+		admin.GET("/users/:id/invites", requireAdmin, inviteHandler.GetUserInvites)
+		admin.GET("/security-events", requireAdmin, auditHandler.ListEvents)
 	}
 
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50052"
+	}
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	proto.RegisterFollowServiceServer(grpcServer, handlers.NewFollowGRPCHandler(followRepo))
+	proto.RegisterUserRelationServiceServer(grpcServer, handlers.NewRelationGRPCHandler(relationRepo, moderationRepo))
+	proto.RegisterUserServiceServer(grpcServer, handlers.NewUserGRPCHandler(userRepo, relationRepo, followRepo, tokens, accessTokens))
+	reflection.Register(grpcServer)
+	go func() {
+		log.Printf("User service gRPC server listening on port %s", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve gRPC: %v", err)
+		}
+	}()
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8081"
@@ -67,3 +305,129 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// bootstrapAdmins grants the admin role to already registered users listed in ADMIN_USERNAMES,
+// otherwise nobody would be able to call the admin endpoints.
+func bootstrapAdmins(userRepo *repositories.UserRepository, roleRepo *repositories.RoleRepository, usernames string) {
+	if usernames == "" {
+		return
+	}
+	adminRole, err := roleRepo.FindByName(models.RoleAdmin)
+	if err != nil || adminRole == nil {
+		log.Fatalf("Failed to find admin role: %v", err)
+	}
+	for _, username := range strings.Split(usernames, ",") {
+		user, err := userRepo.FindByUsername(strings.TrimSpace(username))
+		if err != nil {
+			log.Fatalf("Failed to find user %s: %v", username, err)
+		}
+		if user == nil {
+			log.Printf("Admin user %s is not registered yet", username)
+			continue
+		}
+		if !user.HasRole(models.RoleAdmin) {
+			if err = roleRepo.AssignRole(user, adminRole); err != nil {
+				log.Fatalf("Failed to grant admin role to %s: %v", username, err)
+			}
+		}
+	}
+}
+
+// loadKeyRing reads the signing key from JWT_SIGNING_KEY_FILE. Keys from JWT_PREVIOUS_KEY_FILES only verify tokens,
+// they are kept in the configuration after a manual rotation until tokens signed with them expire.
+func loadKeyRing(overlap time.Duration) *auth.KeyRing {
+	var active *auth.SigningKey
+	var err error
+	if keyFile := os.Getenv("JWT_SIGNING_KEY_FILE"); keyFile != "" {
+		if active, err = auth.LoadSigningKey(keyFile); err != nil {
+			log.Fatalf("Failed to load signing key: %v", err)
+		}
+	} else {
+		log.Println("JWT_SIGNING_KEY_FILE is not set, tokens are signed with a generated key and become invalid on restart")
+		if active, err = auth.GenerateSigningKey(); err != nil {
+			log.Fatalf("Failed to generate signing key: %v", err)
+		}
+	}
+
+	var previous []*auth.SigningKey
+	if keyFiles := os.Getenv("JWT_PREVIOUS_KEY_FILES"); keyFiles != "" {
+		for _, keyFile := range strings.Split(keyFiles, ",") {
+			key, err := auth.LoadSigningKey(strings.TrimSpace(keyFile))
+			if err != nil {
+				log.Fatalf("Failed to load previous signing key: %v", err)
+			}
+			previous = append(previous, key)
+		}
+	}
+	log.Printf("Signing access tokens with %s key %s", active.Method.Alg(), active.ID)
+	return auth.NewKeyRing(active, overlap, previous...)
+}
+
+// loadPasswordHasher hashes with argon2id, raising the parameters upgrades stored hashes on the next login.
+// Hashes made with bcrypt before the switch are still accepted and upgraded the same way.
+func loadPasswordHasher() *passwords.Hasher {
+	params := passwords.DefaultArgon2idParams()
+	params.Memory = uint32(intFromEnv("PASSWORD_ARGON2_MEMORY_KIB", int(params.Memory)))
+	params.Iterations = uint32(intFromEnv("PASSWORD_ARGON2_ITERATIONS", int(params.Iterations)))
+	params.Parallelism = uint8(intFromEnv("PASSWORD_ARGON2_PARALLELISM", int(params.Parallelism)))
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations == 0 || params.Parallelism == 0 {
+		log.Fatalf("Invalid argon2id parameters: %+v", params)
+	}
+	return passwords.NewHasher(passwords.NewArgon2id(params), passwords.NewBcrypt(bcrypt.DefaultCost))
+}
+
+// registrationInviteOnly reads REGISTRATION_MODE, open registration is the default,
+// "invite" requires an invite code from an existing user to register.
+func registrationInviteOnly() bool {
+	switch mode := os.Getenv("REGISTRATION_MODE"); mode {
+	case "", "open":
+		return false
+	case "invite":
+		return true
+	default:
+		log.Fatalf("Invalid REGISTRATION_MODE %q, expected open or invite", mode)
+		return false
+	}
+}
+
+// loadPasswordPolicy reads the rules for new passwords, PASSWORD_BREACHED_LIST_FILE holds SHA-1 hashes
+// of compromised passwords, one per line as in Have I Been Pwned dumps.
+func loadPasswordPolicy() *passwords.Policy {
+	config := passwords.PolicyConfig{
+		MinLength:           intFromEnv("PASSWORD_MIN_LENGTH", 8),
+		MinCharacterClasses: intFromEnv("PASSWORD_MIN_CHARACTER_CLASSES", 2),
+	}
+	var breached *passwords.BreachedList
+	if path := os.Getenv("PASSWORD_BREACHED_LIST_FILE"); path != "" {
+		var err error
+		if breached, err = passwords.LoadBreachedList(path); err != nil {
+			log.Fatalf("Failed to load breached password list: %v", err)
+		}
+		log.Printf("Loaded %d breached password hashes", breached.Size())
+	}
+	return passwords.NewPolicy(config, breached)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration in %s: %v", key, err)
+	}
+	return duration
+}
+
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid number in %s: %v", key, err)
+	}
+	return number
+}
@@ -1,15 +1,17 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"social-network/user-service/auth"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-func AuthMiddleware(jwtKey string) gin.HandlerFunc {
+func AuthMiddleware(tokens *auth.TokenManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,28 +29,23 @@ func AuthMiddleware(jwtKey string) gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(jwtKey), nil
-		})
-
-		if err != nil {
+		claims, err := tokens.ParseAccessToken(tokenString)
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrSessionRevoked) || errors.Is(err, auth.ErrTokenOutdated) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid token: %v", err.Error())})
 			c.Abort()
 			return
 		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := uint(claims["user_id"].(float64))
-			c.Set("userID", userID)
-			c.Set("username", claims["username"].(string))
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if err != nil {
+			log.Printf("Error during AuthMiddleware.ParseAccessToken: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
 			c.Abort()
 			return
 		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("roles", claims.Roles)
+		c.Next()
 	}
 }
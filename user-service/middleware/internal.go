@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InternalTokenHeader carries the shared secret of the services allowed to call /internal endpoints.
+const InternalTokenHeader = "X-Internal-Token"
+
+// RequireInternalToken lets the request through only if it carries the shared internal token.
+// With an empty token every request is refused, so a missing config doesn't open the endpoint.
+func RequireInternalToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(InternalTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid internal token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
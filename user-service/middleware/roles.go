@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"social-network/user-service/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRoles lets the request through if the caller has at least one of the given roles.
+// It must be used after AuthMiddleware.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		callerRoles, exists := c.Get("roles")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		for _, callerRole := range callerRoles.([]string) {
+			for _, role := range roles {
+				if callerRole == role {
+					c.Next()
+					return
+				}
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission lets the request through if one of the caller's roles grants the permission.
+// Roles are read from the database, not from the token, so a revoked role takes effect at once.
+// It must be used after AuthMiddleware.
+func RequirePermission(roleRepo *repositories.RoleRepository, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := roleRepo.HasPermission(c.GetUint("userID"), permission)
+		if err != nil {
+			log.Printf("Error during RequirePermission.HasPermission: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
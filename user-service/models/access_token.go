@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ScopePostsRead  = "posts:read"
+	ScopePostsWrite = "posts:write"
+)
+
+// AccessTokenScopes are the scopes a personal access token may be granted.
+var AccessTokenScopes = []string{ScopePostsRead, ScopePostsWrite}
+
+// PersonalAccessToken lets scripts call the API without the user's password, only the hash of the token is stored.
+type PersonalAccessToken struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"index;not null"`
+	Name   string `gorm:"not null"`
+	// Prefix is the beginning of the token, so the user can tell their tokens apart
+	Prefix    string `gorm:"not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	// Scopes are separated by spaces, as in OAuth
+	Scopes     string `gorm:"not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+func (t *PersonalAccessToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
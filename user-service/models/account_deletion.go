@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AccountDeletion is a deletion request, after completion it stays as the audit record of the erasure,
+// so it must not contain any personal data besides the id of the erased user.
+type AccountDeletion struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	RequestedAt  time.Time  `json:"requested_at" gorm:"not null"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"index;not null"`
+	CanceledAt   *time.Time `json:"canceled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	// PostsAnonymized tells whether posts were anonymized instead of deleted
+	PostsAnonymized bool   `json:"posts_anonymized" gorm:"not null;default:false"`
+	PostsErased     int64  `json:"posts_erased" gorm:"not null;default:0"`
+	Attempts        int    `json:"attempts" gorm:"not null;default:0"`
+	LastError       string `json:"last_error,omitempty"`
+}
+
+func (d *AccountDeletion) IsPending() bool {
+	return d.CanceledAt == nil && d.CompletedAt == nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	AuditRegistered               = "registered"
+	AuditLoginSucceeded           = "login_succeeded"
+	AuditLoginFailed              = "login_failed"
+	AuditProfileUpdated           = "profile_updated"
+	AuditEmailChanged             = "email_changed"
+	AuditPasswordChanged          = "password_changed"
+	AuditPasswordReset            = "password_reset"
+	AuditSessionRevoked           = "session_revoked"
+	AuditAccessTokenRevoked       = "access_token_revoked"
+	AuditTwoFactorEnabled         = "two_factor_enabled"
+	AuditTwoFactorDisabled        = "two_factor_disabled"
+	AuditRecoveryCodesRegenerated = "recovery_codes_regenerated"
+	AuditDeletionScheduled        = "account_deletion_scheduled"
+	AuditDeletionCanceled         = "account_deletion_canceled"
+)
+
+// AuditEventTypes lists the event types accepted by the admin filter.
+var AuditEventTypes = []string{
+	AuditRegistered,
+	AuditLoginSucceeded,
+	AuditLoginFailed,
+	AuditProfileUpdated,
+	AuditEmailChanged,
+	AuditPasswordChanged,
+	AuditPasswordReset,
+	AuditSessionRevoked,
+	AuditAccessTokenRevoked,
+	AuditTwoFactorEnabled,
+	AuditTwoFactorDisabled,
+	AuditRecoveryCodesRegenerated,
+	AuditDeletionScheduled,
+	AuditDeletionCanceled,
+}
+
+// AuditEvent is an entry of the security audit log of a user, entries are never updated.
+type AuditEvent struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"index:idx_audit_events_user_created;not null"`
+	Type      string `json:"type" gorm:"index;not null"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	// Details say what exactly happened, e.g. which fields of the profile were changed
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_audit_events_user_created;index"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// AvatarSizes are the square thumbnails generated for every avatar, in pixels.
+var AvatarSizes = []int{64, 128, 256}
+
+// AvatarKey is the blob storage key of a thumbnail. Every upload gets a new version,
+// so the URLs never change their content and can be cached forever.
+func AvatarKey(userID uint, version string, size int) string {
+	return fmt.Sprintf("avatars/%d/%s-%d.jpg", userID, version, size)
+}
+
+// AvatarURLs maps thumbnail sizes to the URLs served through the api-gateway, nil if there is no avatar.
+func AvatarURLs(userID uint, version string) map[string]string {
+	if version == "" {
+		return nil
+	}
+	urls := make(map[string]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		urls[strconv.Itoa(size)] = fmt.Sprintf("/api/users/avatars/%d/%s-%d.jpg", userID, version, size)
+	}
+	return urls
+}
+
+func (u *User) AfterFind(tx *gorm.DB) error {
+	u.AvatarURLs = AvatarURLs(u.ID, u.AvatarVersion)
+	return nil
+}
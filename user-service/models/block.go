@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Block hides each user's content from the other one, a blocked user can't see the blocker's posts.
+type Block struct {
+	BlockerID uint `gorm:"primaryKey;autoIncrement:false"`
+	BlockedID uint `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time
+}
+
+// Mute hides the muted user's posts from the muter's listings only, the muted user doesn't notice anything.
+type Mute struct {
+	MuterID   uint `gorm:"primaryKey;autoIncrement:false"`
+	MutedID   uint `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time
+}
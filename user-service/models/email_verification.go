@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type EmailVerificationToken struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"index;not null"`
+	// Email is the address the token was sent to, it must still match the user's email on verification
+	Email     string    `gorm:"not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Follow is a directed edge of the social graph, FollowerID follows FolloweeID.
+type Follow struct {
+	FollowerID uint `gorm:"primaryKey;autoIncrement:false"`
+	FolloweeID uint `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// InviteCode lets up to MaxUses people register while registration is invite-only, only the hash of the code is stored.
+type InviteCode struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedByID uint `gorm:"index;not null"`
+	// Prefix is the beginning of the code, so the creator can tell their codes apart
+	Prefix    string `gorm:"not null"`
+	CodeHash  string `gorm:"uniqueIndex;not null"`
+	MaxUses   int    `gorm:"not null"`
+	UsedCount int    `gorm:"not null;default:0"`
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (i *InviteCode) IsUsable(now time.Time) bool {
+	return i.RevokedAt == nil && i.UsedCount < i.MaxUses && (i.ExpiresAt == nil || now.Before(*i.ExpiresAt))
+}
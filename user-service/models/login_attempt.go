@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type LoginFailure struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Username string `json:"username" gorm:"index;not null"`
+	// UserID is nil if nobody is registered with the username
+	UserID    *uint     `json:"user_id"`
+	IP        string    `json:"ip" gorm:"index;not null"`
+	UserAgent string    `json:"user_agent"`
+	Cleared   bool      `json:"cleared" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+type AccountLockout struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Username       string     `json:"username" gorm:"index;not null"`
+	UserID         *uint      `json:"user_id"`
+	IP             string     `json:"ip"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    time.Time  `json:"locked_until"`
+	UnlockedAt     *time.Time `json:"unlocked_at"`
+	UnlockedByID   *uint      `json:"unlocked_by_id"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+}
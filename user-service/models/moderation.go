@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	ModerationSuspend   = "suspend"
+	ModerationBan       = "ban"
+	ModerationReinstate = "reinstate"
+)
+
+// ModerationAction is the audit record of a suspension, ban or reinstatement, it is kept after the restriction ends.
+type ModerationAction struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	UserID  uint   `json:"user_id" gorm:"index;not null"`
+	AdminID uint   `json:"admin_id" gorm:"index;not null"`
+	Action  string `json:"action" gorm:"not null"`
+	Reason  string `json:"reason" gorm:"not null"`
+	// SuspendedUntil is set for suspensions only, bans last until the user is reinstated
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+}
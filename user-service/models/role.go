@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+
+	PermissionManageRoles   = "roles:manage"
+	PermissionModerateUsers = "users:moderate"
+)
+
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Descr       string       `json:"descr"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:roles_permissions;"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type Permission struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Name  string `json:"name" gorm:"uniqueIndex;not null"`
+	Descr string `json:"descr"`
+}
+
+// DefaultRoles are created on startup if they don't exist yet.
+var DefaultRoles = []Role{
+	{
+		Name:  RoleUser,
+		Descr: "Regular user",
+	},
+	{
+		Name:  RoleModerator,
+		Descr: "Can moderate users",
+		Permissions: []Permission{
+			{Name: PermissionModerateUsers, Descr: "Suspend and ban users"},
+		},
+	},
+	{
+		Name:  RoleAdmin,
+		Descr: "Full access",
+		Permissions: []Permission{
+			{Name: PermissionManageRoles, Descr: "Grant and revoke roles"},
+			{Name: PermissionModerateUsers, Descr: "Suspend and ban users"},
+		},
+	},
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Session struct {
+	gorm.Model
+	UserID           uint       `json:"user_id" gorm:"index;not null"`
+	RefreshTokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	// the client which logged in, shown to the user in the list of their devices
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	DeviceName string    `json:"device_name"`
+	LastSeenAt time.Time `json:"last_seen_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RotatedRefreshToken keeps hashes of refresh tokens that were already exchanged,
+// so that presenting one of them again can be detected as reuse.
+type RotatedRefreshToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index;not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time
+}
@@ -5,13 +5,80 @@ import (
 	"time"
 )
 
+// Visibility levels of profile fields, the owner always sees all of their fields.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityPrivate   = "private"
+)
+
 type User struct {
 	gorm.Model
-	Username    string     `json:"username" gorm:"uniqueIndex;not null"`
-	Password    string     `json:"-" gorm:"not null"`
-	FirstName   string     `json:"first_name"`
-	LastName    string     `json:"last_name"`
-	Email       string     `json:"email" gorm:"uniqueIndex;not null"`
-	BirthDate   *time.Time `json:"birth_date"`
-	PhoneNumber string     `json:"phone_number"`
+	Username         string     `json:"username" gorm:"uniqueIndex;not null"`
+	Password         string     `json:"-" gorm:"not null"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	DisplayName      string     `json:"display_name"`
+	Bio              string     `json:"bio"`
+	Location         string     `json:"location"`
+	Website          string     `json:"website"`
+	Links            []string   `json:"links,omitempty" gorm:"serializer:json"`
+	Email            string     `json:"email" gorm:"uniqueIndex;not null"`
+	EmailVerified    bool       `json:"email_verified" gorm:"not null;default:false"`
+	BirthDate        *time.Time `json:"birth_date"`
+	PhoneNumber      string     `json:"phone_number"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled" gorm:"not null;default:false"`
+	// TokenVersion is embedded into access tokens and incremented on every password and role change,
+	// tokens with an older version are rejected
+	TokenVersion uint `json:"-" gorm:"not null;default:0"`
+	// moderation state, ModerationAction keeps the history
+	SuspendedUntil   *time.Time `json:"-" gorm:"index"`
+	BannedAt         *time.Time `json:"-" gorm:"index"`
+	ModerationReason string     `json:"-"`
+	// AvatarVersion is empty until the user uploads an avatar
+	AvatarVersion string            `json:"-"`
+	AvatarURLs    map[string]string `json:"avatar_urls,omitempty" gorm:"-"`
+	// contacts and birth date are hidden from other users unless the owner shows them to everyone or to followers
+	EmailVisibility       string `json:"email_visibility" gorm:"not null;default:private"`
+	PhoneNumberVisibility string `json:"phone_number_visibility" gorm:"not null;default:private"`
+	BirthDateVisibility   string `json:"birth_date_visibility" gorm:"not null;default:private"`
+	// who invited the user and with which code, empty for users who registered without an invite
+	InvitedByID  *uint  `json:"invited_by_id,omitempty" gorm:"index"`
+	InviteCodeID *uint  `json:"-" gorm:"index"`
+	Roles        []Role `json:"roles,omitempty" gorm:"many2many:users_roles;"`
+}
+
+// IsVisible reports whether a field with the visibility is shown to another user, follower tells if they follow the owner.
+func IsVisible(visibility string, follower bool) bool {
+	return visibility == VisibilityPublic || (visibility == VisibilityFollowers && follower)
+}
+
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+func (u *User) IsSuspended(now time.Time) bool {
+	return u.SuspendedUntil != nil && now.Before(*u.SuspendedUntil)
+}
+
+// IsRestricted reports whether the user is banned or suspended, restricted users can't log in.
+func (u *User) IsRestricted(now time.Time) bool {
+	return u.IsBanned() || u.IsSuspended(now)
+}
+
+func (u *User) RoleNames() []string {
+	names := make([]string, len(u.Roles))
+	for i, role := range u.Roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
+func (u *User) HasRole(name string) bool {
+	for _, role := range u.Roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+)
+
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	// Data holds machine readable values of the message, e.g. one-time tokens.
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// Notifier delivers messages to users. Real deployments would send emails,
+// the implementations below are meant for local development and tests.
+type Notifier interface {
+	Notify(message Message) error
+}
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(message Message) error {
+	log.Printf("Notification to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// FileNotifier appends every message as a JSON line to the file.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(message Message) error {
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// ReadMessages returns all messages written to the file so far.
+func (n *FileNotifier) ReadMessages() ([]Message, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	data, err := os.ReadFile(n.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var message Message
+		if err = decoder.Decode(&message); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
@@ -0,0 +1,52 @@
+package passwords
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptMaxLength is the number of bytes bcrypt looks at, the rest of a longer password is ignored.
+const bcryptMaxLength = 72
+
+// Bcrypt verifies the hashes made before the switch to argon2id, their ids are 2a, 2b and 2y.
+type Bcrypt struct {
+	cost int
+}
+
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	return string(hash), err
+}
+
+func (b *Bcrypt) Verify(password, encoded string) (bool, error) {
+	// otherwise any password sharing the first 72 bytes would match and replace the real one on rehash
+	if len(password) > bcryptMaxLength {
+		return false, nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ErrMalformedHash
+	}
+	return true, nil
+}
+
+func (b *Bcrypt) Owns(encoded string) bool {
+	switch schemeID(encoded) {
+	case "2a", "2b", "2y":
+		return true
+	}
+	return false
+}
+
+func (b *Bcrypt) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.cost
+}
@@ -0,0 +1,77 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	sha1HexLength = 40
+	// prefixLength matches the k-anonymity ranges of Have I Been Pwned, so its dumps can be used as is
+	prefixLength = 5
+)
+
+// BreachedList is a local corpus of compromised passwords. It holds SHA-1 hashes only,
+// grouped into buckets by the first 5 hex digits of the hash.
+type BreachedList struct {
+	buckets map[string]map[string]struct{}
+	size    int
+}
+
+// LoadBreachedList reads a file with one uppercase or lowercase SHA-1 hex hash per line.
+// A ":count" suffix, as in Have I Been Pwned dumps, is ignored, as are empty lines and lines starting with #.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &BreachedList{buckets: make(map[string]map[string]struct{})}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1HexLength {
+			return nil, fmt.Errorf("line %d of %s is not a SHA-1 hash", lineNumber, path)
+		}
+		list.add(hash)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (l *BreachedList) add(hash string) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+	bucket, ok := l.buckets[prefix]
+	if !ok {
+		bucket = make(map[string]struct{})
+		l.buckets[prefix] = bucket
+	}
+	if _, ok = bucket[suffix]; !ok {
+		bucket[suffix] = struct{}{}
+		l.size++
+	}
+}
+
+// Size returns the number of distinct hashes in the list.
+func (l *BreachedList) Size() int {
+	return l.size
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := l.buckets[hash[:prefixLength]][hash[prefixLength:]]
+	return ok
+}
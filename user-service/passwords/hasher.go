@@ -0,0 +1,58 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrUnknownScheme = errors.New("unknown password hash scheme")
+
+// Scheme is one hashing algorithm. Hashes are PHC strings, $<id>$..., so the scheme of a stored hash is known.
+type Scheme interface {
+	// Hash returns the PHC string of the password with a fresh salt.
+	Hash(password string) (string, error)
+	// Verify compares the password with the hash, it returns an error only if the hash is malformed.
+	Verify(password, encoded string) (bool, error)
+	// Owns reports whether the hash was made by this scheme.
+	Owns(encoded string) bool
+	// Outdated reports whether the hash of this scheme was made with other parameters than the current ones.
+	Outdated(encoded string) bool
+}
+
+// Hasher hashes new passwords with the current scheme and still verifies hashes of the legacy ones.
+type Hasher struct {
+	current Scheme
+	legacy  []Scheme
+}
+
+func NewHasher(current Scheme, legacy ...Scheme) *Hasher {
+	return &Hasher{current: current, legacy: legacy}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify checks the password, needsRehash is set on a match if the hash should be replaced by a current one.
+func (h *Hasher) Verify(password, encoded string) (ok bool, needsRehash bool, err error) {
+	if h.current.Owns(encoded) {
+		ok, err = h.current.Verify(password, encoded)
+		return ok, ok && h.current.Outdated(encoded), err
+	}
+	for _, scheme := range h.legacy {
+		if scheme.Owns(encoded) {
+			ok, err = scheme.Verify(password, encoded)
+			return ok, ok, err
+		}
+	}
+	return false, false, ErrUnknownScheme
+}
+
+// schemeID returns the id of a PHC string, e.g. argon2id for $argon2id$v=19$...
+func schemeID(encoded string) string {
+	if !strings.HasPrefix(encoded, "$") {
+		return ""
+	}
+	id, _, _ := strings.Cut(encoded[1:], "$")
+	return id
+}
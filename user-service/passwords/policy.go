@@ -0,0 +1,107 @@
+package passwords
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	RuleMinLength        = "min_length"
+	RuleCharacterClasses = "character_classes"
+	RuleContainsUsername = "contains_username"
+	RuleContainsEmail    = "contains_email"
+	RuleBreached         = "breached"
+)
+
+// minIdentifierLength keeps short usernames and emails like "new@..." from rejecting lots of ordinary passwords.
+const minIdentifierLength = 4
+
+// Violation is one broken rule of the policy, Rule is stable for clients and Message is for people.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type PolicyConfig struct {
+	// MinLength is in characters, not bytes
+	MinLength int
+	// MinCharacterClasses is how many of lowercase, uppercase, digits and other characters are required
+	MinCharacterClasses int
+}
+
+// Policy decides which passwords can be set. Breached is optional, without it the corpus isn't checked.
+type Policy struct {
+	config   PolicyConfig
+	breached *BreachedList
+}
+
+func NewPolicy(config PolicyConfig, breached *BreachedList) *Policy {
+	return &Policy{config: config, breached: breached}
+}
+
+// Check returns every rule the password breaks, so the user can fix all of them at once.
+func (p *Policy) Check(password, username, email string) []Violation {
+	var violations []Violation
+	if utf8.RuneCountInString(password) < p.config.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.config.MinLength),
+		})
+	}
+	if characterClasses(password) < p.config.MinCharacterClasses {
+		violations = append(violations, Violation{
+			Rule: RuleCharacterClasses,
+			Message: fmt.Sprintf("Password must contain at least %d of lowercase letters, uppercase letters, digits and symbols",
+				p.config.MinCharacterClasses),
+		})
+	}
+
+	lowered := strings.ToLower(password)
+	if containsIdentifier(lowered, username) {
+		violations = append(violations, Violation{Rule: RuleContainsUsername, Message: "Password must not contain the username"})
+	}
+	localPart, _, _ := strings.Cut(email, "@")
+	if containsIdentifier(lowered, email) || containsIdentifier(lowered, localPart) {
+		violations = append(violations, Violation{Rule: RuleContainsEmail, Message: "Password must not contain the email"})
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		violations = append(violations, Violation{
+			Rule:    RuleBreached,
+			Message: "Password appeared in a data breach, choose another one",
+		})
+	}
+	return violations
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+func containsIdentifier(loweredPassword, identifier string) bool {
+	if utf8.RuneCountInString(identifier) < minIdentifierLength {
+		return false
+	}
+	return strings.Contains(loweredPassword, strings.ToLower(identifier))
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenRepository(db *gorm.DB) *AccessTokenRepository {
+	return &AccessTokenRepository{db: db}
+}
+
+func (r *AccessTokenRepository) Create(token *models.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *AccessTokenRepository) FindByHash(hash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUser returns tokens which are not revoked, expired ones included.
+func (r *AccessTokenRepository) ListByUser(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke returns false if the user has no such token or it is already revoked.
+func (r *AccessTokenRepository) Revoke(userID, tokenID uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AccessTokenRepository) TouchLastUsed(tokenID uint, now time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).Where("id = ?", tokenID).Update("last_used_at", now).Error
+}
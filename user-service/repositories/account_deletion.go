@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccountDeletionRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountDeletionRepository(db *gorm.DB) *AccountDeletionRepository {
+	return &AccountDeletionRepository{db: db}
+}
+
+func (r *AccountDeletionRepository) Create(deletion *models.AccountDeletion) error {
+	return r.db.Create(deletion).Error
+}
+
+// FindPending returns the deletion which is neither canceled nor completed.
+func (r *AccountDeletionRepository) FindPending(userID uint) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := r.db.Where("user_id = ? AND canceled_at IS NULL AND completed_at IS NULL", userID).
+		First(&deletion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r *AccountDeletionRepository) Cancel(deletion *models.AccountDeletion, now time.Time) error {
+	deletion.CanceledAt = &now
+	return r.db.Model(deletion).Update("canceled_at", now).Error
+}
+
+func (r *AccountDeletionRepository) ListDue(now time.Time, limit int) ([]models.AccountDeletion, error) {
+	var deletions []models.AccountDeletion
+	err := r.db.Where("canceled_at IS NULL AND completed_at IS NULL AND scheduled_for <= ?", now).
+		Order("scheduled_for").Limit(limit).
+		Find(&deletions).Error
+	return deletions, err
+}
+
+func (r *AccountDeletionRepository) RecordFailure(deletion *models.AccountDeletion, message string) error {
+	deletion.Attempts++
+	deletion.LastError = message
+	return r.db.Model(deletion).Updates(map[string]interface{}{
+		"attempts":     deletion.Attempts,
+		"last_error":   message,
+		"posts_erased": deletion.PostsErased,
+	}).Error
+}
+
+type erasedRows struct {
+	model interface{}
+	query string
+	args  []interface{}
+}
+
+// EraseUser permanently deletes the user with all their data and completes the deletion in one transaction.
+func (r *AccountDeletionRepository) EraseUser(deletion *models.AccountDeletion, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Unscoped().Select("id", "username").First(&user, deletion.UserID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		userID := deletion.UserID
+
+		sessionIDs := tx.Unscoped().Model(&models.Session{}).Select("id").Where("user_id = ?", userID)
+		rows := []erasedRows{
+			{&models.RotatedRefreshToken{}, "session_id IN (?)", []interface{}{sessionIDs}},
+			{&models.Session{}, "user_id = ?", []interface{}{userID}},
+			{&models.PasswordResetToken{}, "user_id = ?", []interface{}{userID}},
+			{&models.EmailVerificationToken{}, "user_id = ?", []interface{}{userID}},
+			{&models.TwoFactor{}, "user_id = ?", []interface{}{userID}},
+			{&models.RecoveryCode{}, "user_id = ?", []interface{}{userID}},
+			{&models.LoginChallenge{}, "user_id = ?", []interface{}{userID}},
+			{&models.PersonalAccessToken{}, "user_id = ?", []interface{}{userID}},
+			{&models.Follow{}, "follower_id = ? OR followee_id = ?", []interface{}{userID, userID}},
+			{&models.Block{}, "blocker_id = ? OR blocked_id = ?", []interface{}{userID, userID}},
+			{&models.Mute{}, "muter_id = ? OR muted_id = ?", []interface{}{userID, userID}},
+			// failed logins and lockouts are also kept by username, which is personal data too
+			{&models.LoginFailure{}, "user_id = ? OR username = ?", []interface{}{userID, user.Username}},
+			{&models.AccountLockout{}, "user_id = ? OR username = ?", []interface{}{userID, user.Username}},
+			{&models.AuditEvent{}, "user_id = ?", []interface{}{userID}},
+			{&models.InviteCode{}, "created_by_id = ?", []interface{}{userID}},
+		}
+		for _, erased := range rows {
+			if err = tx.Unscoped().Where(erased.query, erased.args...).Delete(erased.model).Error; err != nil {
+				return err
+			}
+		}
+		if err = tx.Exec("DELETE FROM users_roles WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		// people the user invited keep their accounts, just without the link to the erased one
+		err = tx.Unscoped().Model(&models.User{}).Where("invited_by_id = ?", userID).
+			Updates(map[string]interface{}{"invited_by_id": nil, "invite_code_id": nil}).Error
+		if err != nil {
+			return err
+		}
+		if err = tx.Unscoped().Delete(&models.User{}, userID).Error; err != nil {
+			return err
+		}
+
+		deletion.CompletedAt = &now
+		deletion.Attempts++
+		deletion.LastError = ""
+		return tx.Save(deletion).Error
+	})
+}
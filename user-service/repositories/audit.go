@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository stores the security audit log, it can only append and read events.
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// AuditFilter selects events for admins, zero fields are not filtered on.
+type AuditFilter struct {
+	UserID uint
+	Type   string
+	From   *time.Time
+	To     *time.Time
+}
+
+func (r *AuditRepository) Create(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+// ListEvents returns events from the newest to the oldest.
+func (r *AuditRepository) ListEvents(filter AuditFilter, page, pageSize int) ([]models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []models.AuditEvent
+	err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, count, nil
+}
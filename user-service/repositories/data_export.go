@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"social-network/user-service/models"
+
+	"gorm.io/gorm"
+)
+
+// DataExportRepository reads everything stored about a user for the personal data export.
+type DataExportRepository struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) *DataExportRepository {
+	return &DataExportRepository{db: db}
+}
+
+// ListFollows returns follows in both directions.
+func (r *DataExportRepository) ListFollows(userID uint) ([]models.Follow, error) {
+	var follows []models.Follow
+	err := r.db.Where("follower_id = ? OR followee_id = ?", userID, userID).
+		Order("created_at").Find(&follows).Error
+	return follows, err
+}
+
+// ListBlocks returns only blocks made by the user, who blocked the user is the other user's data.
+func (r *DataExportRepository) ListBlocks(userID uint) ([]models.Block, error) {
+	var blocks []models.Block
+	err := r.db.Where("blocker_id = ?", userID).Order("created_at").Find(&blocks).Error
+	return blocks, err
+}
+
+func (r *DataExportRepository) ListMutes(userID uint) ([]models.Mute, error) {
+	var mutes []models.Mute
+	err := r.db.Where("muter_id = ?", userID).Order("created_at").Find(&mutes).Error
+	return mutes, err
+}
+
+func (r *DataExportRepository) ListSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Unscoped().Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *DataExportRepository) ListLoginFailures(userID uint) ([]models.LoginFailure, error) {
+	var failures []models.LoginFailure
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&failures).Error
+	return failures, err
+}
+
+func (r *DataExportRepository) ListLockouts(userID uint) ([]models.AccountLockout, error) {
+	var lockouts []models.AccountLockout
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&lockouts).Error
+	return lockouts, err
+}
+
+func (r *DataExportRepository) ListAccountDeletions(userID uint) ([]models.AccountDeletion, error) {
+	var deletions []models.AccountDeletion
+	err := r.db.Where("user_id = ?", userID).Order("requested_at").Find(&deletions).Error
+	return deletions, err
+}
+
+func (r *DataExportRepository) ListAuditEvents(userID uint) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := r.db.Where("user_id = ?", userID).Order("created_at, id").Find(&events).Error
+	return events, err
+}
+
+// ListAccessTokens returns all personal access tokens of the user, revoked ones included.
+func (r *DataExportRepository) ListAccessTokens(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&tokens).Error
+	return tokens, err
+}
+
+// FindTwoFactor returns the confirmed two-factor setup of the user or nil.
+func (r *DataExportRepository) FindTwoFactor(userID uint) (*models.TwoFactor, error) {
+	var twoFactors []models.TwoFactor
+	if err := r.db.Where("user_id = ? AND confirmed = ?", userID, true).Limit(1).Find(&twoFactors).Error; err != nil {
+		return nil, err
+	}
+	if len(twoFactors) == 0 {
+		return nil, nil
+	}
+	return &twoFactors[0], nil
+}
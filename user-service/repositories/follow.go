@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"social-network/user-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// Follow returns false if the user already follows the followee.
+func (r *FollowRepository) Follow(followerID, followeeID uint) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Follow{FollowerID: followerID, FolloweeID: followeeID})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Unfollow returns false if there was nothing to remove.
+func (r *FollowRepository) Unfollow(followerID, followeeID uint) (bool, error) {
+	result := r.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&models.Follow{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *FollowRepository) IsFollowing(followerID, followeeID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FollowedAmong returns which of the users are followed by the follower.
+func (r *FollowRepository) FollowedAmong(followerID uint, followeeIDs []uint) (map[uint]bool, error) {
+	followed := make(map[uint]bool)
+	if followerID == 0 || len(followeeIDs) == 0 {
+		return followed, nil
+	}
+	var ids []uint
+	err := r.db.Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id IN ?", followerID, followeeIDs).
+		Pluck("followee_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		followed[id] = true
+	}
+	return followed, nil
+}
+
+func (r *FollowRepository) CountFollowers(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *FollowRepository) CountFollowing(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ListFollowers returns users following userID, the most recent followers first.
+// Users who blocked viewerID are left out, a zero viewerID lists everyone.
+func (r *FollowRepository) ListFollowers(userID, viewerID uint, page, pageSize int) ([]models.User, int64, error) {
+	return listRelatedUsers(r.db.Scopes(visibleTo(viewerID)),
+		"follows", "follows.follower_id", "follows.followee_id", userID, page, pageSize)
+}
+
+// ListFollowing returns users followed by userID, the most recently followed first.
+// Users who blocked viewerID are left out, a zero viewerID lists everyone.
+func (r *FollowRepository) ListFollowing(userID, viewerID uint, page, pageSize int) ([]models.User, int64, error) {
+	return listRelatedUsers(r.db.Scopes(visibleTo(viewerID)),
+		"follows", "follows.followee_id", "follows.follower_id", userID, page, pageSize)
+}
+
+// listRelatedUsers pages through users joined with a relation table, the most recent relations first.
+func listRelatedUsers(db *gorm.DB, table, userColumn, filterColumn string, userID uint, page, pageSize int) ([]models.User, int64, error) {
+	query := db.Model(&models.User{}).
+		Joins("JOIN "+table+" ON users.id = "+userColumn).
+		Where(filterColumn+" = ?", userID)
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	err := query.Order(table + ".created_at DESC").Order("users.id").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, count, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InviteRepository struct {
+	db *gorm.DB
+}
+
+func NewInviteRepository(db *gorm.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+func (r *InviteRepository) Create(invite *models.InviteCode) error {
+	return r.db.Create(invite).Error
+}
+
+func (r *InviteRepository) FindByHash(hash string) (*models.InviteCode, error) {
+	var invite models.InviteCode
+	if err := r.db.Where("code_hash = ?", hash).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListByCreator returns all codes of the user, used up, expired and revoked ones included.
+func (r *InviteRepository) ListByCreator(creatorID uint) ([]models.InviteCode, error) {
+	invites := []models.InviteCode{}
+	err := r.db.Where("created_by_id = ?", creatorID).Order("created_at DESC").Order("id DESC").Find(&invites).Error
+	return invites, err
+}
+
+// ListInvitees returns the users who registered with the given codes, in the order they joined.
+func (r *InviteRepository) ListInvitees(inviteIDs []uint) ([]models.User, error) {
+	users := []models.User{}
+	if len(inviteIDs) == 0 {
+		return users, nil
+	}
+	err := r.db.Select("id", "username", "created_at", "invite_code_id").
+		Where("invite_code_id IN ?", inviteIDs).Order("created_at").Order("id").Find(&users).Error
+	return users, err
+}
+
+// Redeem takes one use of the code, it returns false if the code was used up, expired or revoked meanwhile.
+// The check and the increment are one statement, so concurrent registrations can't exceed MaxUses.
+func (r *InviteRepository) Redeem(inviteID uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.InviteCode{}).
+		Where("id = ? AND revoked_at IS NULL AND used_count < max_uses AND (expires_at IS NULL OR expires_at > ?)",
+			inviteID, now).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release gives back a use taken by Redeem, e.g. when the registration failed afterwards.
+func (r *InviteRepository) Release(inviteID uint) error {
+	return r.db.Model(&models.InviteCode{}).
+		Where("id = ? AND used_count > 0", inviteID).
+		Update("used_count", gorm.Expr("used_count - 1")).Error
+}
+
+// Revoke returns false if the user has no such code or it is already revoked.
+func (r *InviteRepository) Revoke(creatorID, inviteID uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.InviteCode{}).
+		Where("id = ? AND created_by_id = ? AND revoked_at IS NULL", inviteID, creatorID).
+		Update("revoked_at", now)
+	return result.RowsAffected > 0, result.Error
+}
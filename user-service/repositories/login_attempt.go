@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+func (r *LoginAttemptRepository) CreateFailure(failure *models.LoginFailure) error {
+	return r.db.Create(failure).Error
+}
+
+func (r *LoginAttemptRepository) CountUsernameFailures(username string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LoginFailure{}).
+		Where("username = ? AND cleared = ? AND created_at > ?", username, false, since).
+		Count(&count).Error
+	return count, err
+}
+
+// OldestIPFailure returns the oldest failure from the ip after since and the number of such failures.
+func (r *LoginAttemptRepository) OldestIPFailure(ip string, since time.Time) (*models.LoginFailure, int64, error) {
+	query := r.db.Model(&models.LoginFailure{}).Where("ip = ? AND created_at > ?", ip, since)
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return nil, 0, nil
+	}
+	var failure models.LoginFailure
+	if err := query.Order("created_at").First(&failure).Error; err != nil {
+		return nil, 0, err
+	}
+	return &failure, count, nil
+}
+
+func (r *LoginAttemptRepository) ClearUsernameFailures(username string) error {
+	return r.db.Model(&models.LoginFailure{}).
+		Where("username = ? AND cleared = ?", username, false).
+		Update("cleared", true).Error
+}
+
+func (r *LoginAttemptRepository) CreateLockout(lockout *models.AccountLockout) error {
+	return r.db.Create(lockout).Error
+}
+
+// FindActiveLockout returns the lockout which lasts the longest after now, if any.
+func (r *LoginAttemptRepository) FindActiveLockout(username string, now time.Time) (*models.AccountLockout, error) {
+	var lockout models.AccountLockout
+	err := r.db.Where("username = ? AND unlocked_at IS NULL AND locked_until > ?", username, now).
+		Order("locked_until DESC").
+		First(&lockout).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+func (r *LoginAttemptRepository) CountLockouts(username string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AccountLockout{}).
+		Where("username = ? AND created_at > ?", username, since).
+		Count(&count).Error
+	return count, err
+}
+
+// Unlock ends all active lockouts of the username and forgets its failed attempts.
+func (r *LoginAttemptRepository) Unlock(username string, unlockedByID uint, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AccountLockout{}).
+			Where("username = ? AND unlocked_at IS NULL AND locked_until > ?", username, now).
+			Updates(map[string]interface{}{"unlocked_at": now, "unlocked_by_id": unlockedByID}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.LoginFailure{}).
+			Where("username = ? AND cleared = ?", username, false).
+			Update("cleared", true).Error
+	})
+}
+
+func (r *LoginAttemptRepository) ListLockouts(page, pageSize int, username, ip string) ([]models.AccountLockout, int64, error) {
+	query := r.db.Model(&models.AccountLockout{})
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var lockouts []models.AccountLockout
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&lockouts).Error; err != nil {
+		return nil, 0, err
+	}
+	return lockouts, count, nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"social-network/user-service/models"
+
+	"gorm.io/gorm"
+)
+
+type ModerationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// Apply stores the moderation state of the user together with the audit record of the action.
+func (r *ModerationRepository) Apply(user *models.User, action *models.ModerationAction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(user).Updates(map[string]interface{}{
+			"suspended_until":   user.SuspendedUntil,
+			"banned_at":         user.BannedAt,
+			"moderation_reason": user.ModerationReason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(action).Error
+	})
+}
+
+// ListActions returns the moderation history of the user, newest first.
+func (r *ModerationRepository) ListActions(userID uint, page, pageSize int) ([]models.ModerationAction, int64, error) {
+	query := r.db.Model(&models.ModerationAction{}).Where("user_id = ?", userID)
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var actions []models.ModerationAction
+	err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&actions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return actions, count, nil
+}
+
+// ListBannedIDs returns ids of banned users, whose content is hidden from everyone.
+func (r *ModerationRepository) ListBannedIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.User{}).Where("banned_at IS NOT NULL").Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"social-network/user-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RelationRepository struct {
+	db *gorm.DB
+}
+
+func NewRelationRepository(db *gorm.DB) *RelationRepository {
+	return &RelationRepository{db: db}
+}
+
+// Block also removes follows in both directions.
+func (r *RelationRepository) Block(blockerID, blockedID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Block{BlockerID: blockerID, BlockedID: blockedID}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).
+			Delete(&models.Follow{}).Error
+	})
+}
+
+func (r *RelationRepository) Unblock(blockerID, blockedID uint) error {
+	return r.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.Block{}).Error
+}
+
+func (r *RelationRepository) Mute(muterID, mutedID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Mute{MuterID: muterID, MutedID: mutedID}).Error
+}
+
+func (r *RelationRepository) Unmute(muterID, mutedID uint) error {
+	return r.db.Where("muter_id = ? AND muted_id = ?", muterID, mutedID).Delete(&models.Mute{}).Error
+}
+
+// IsBlockedEitherWay reports whether one of the users blocked the other one.
+func (r *RelationRepository) IsBlockedEitherWay(firstID, secondID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+			firstID, secondID, secondID, firstID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *RelationRepository) IsBlocking(blockerID, blockedID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Block{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// visibleTo leaves out users who blocked the viewer, to the viewer they look as if they didn't exist.
+func visibleTo(viewerID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if viewerID == 0 {
+			return db
+		}
+		return db.Where("NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = users.id AND blocks.blocked_id = ?)",
+			viewerID)
+	}
+}
+
+// ListBlocked returns users blocked by userID.
+func (r *RelationRepository) ListBlocked(userID uint, page, pageSize int) ([]models.User, int64, error) {
+	return listRelatedUsers(r.db, "blocks", "blocks.blocked_id", "blocks.blocker_id", userID, page, pageSize)
+}
+
+// ListMuted returns users muted by userID.
+func (r *RelationRepository) ListMuted(userID uint, page, pageSize int) ([]models.User, int64, error) {
+	return listRelatedUsers(r.db, "mutes", "mutes.muted_id", "mutes.muter_id", userID, page, pageSize)
+}
+
+// GetRelations returns ids of users blocked by userID, users who blocked userID and users muted by userID.
+func (r *RelationRepository) GetRelations(userID uint) (blocked []uint, blockedBy []uint, muted []uint, err error) {
+	if err = r.db.Model(&models.Block{}).Where("blocker_id = ?", userID).Pluck("blocked_id", &blocked).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	if err = r.db.Model(&models.Block{}).Where("blocked_id = ?", userID).Pluck("blocker_id", &blockedBy).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	if err = r.db.Model(&models.Mute{}).Where("muter_id = ?", userID).Pluck("muted_id", &muted).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	return blocked, blockedBy, muted, nil
+}
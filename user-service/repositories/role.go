@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// EnsureRoles creates missing roles and permissions. Existing roles are left untouched.
+func (r *RoleRepository) EnsureRoles(roles []models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, defaultRole := range roles {
+			var role models.Role
+			err := tx.Where("name = ?", defaultRole.Name).First(&role).Error
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			role = models.Role{Name: defaultRole.Name, Descr: defaultRole.Descr}
+			for _, defaultPermission := range defaultRole.Permissions {
+				var permission models.Permission
+				if err = tx.Where(models.Permission{Name: defaultPermission.Name}).
+					Attrs(models.Permission{Descr: defaultPermission.Descr}).
+					FirstOrCreate(&permission).Error; err != nil {
+					return err
+				}
+				role.Permissions = append(role.Permissions, permission)
+			}
+			if err = tx.Create(&role).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *RoleRepository) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *RoleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// AssignRole and RemoveRole increment the token version of the user,
+// so access tokens carrying the old roles are rejected and have to be refreshed.
+func (r *RoleRepository) AssignRole(user *models.User, role *models.Role) error {
+	return r.changeRoles(user, func(roles *gorm.Association) error {
+		return roles.Append(role)
+	})
+}
+
+func (r *RoleRepository) RemoveRole(user *models.User, role *models.Role) error {
+	return r.changeRoles(user, func(roles *gorm.Association) error {
+		return roles.Delete(role)
+	})
+}
+
+func (r *RoleRepository) changeRoles(user *models.User, change func(roles *gorm.Association) error) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := change(tx.Model(user).Association("Roles")); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).
+			Update("token_version", gorm.Expr("token_version + 1")).Error
+	})
+	if err != nil {
+		return err
+	}
+	if err = r.db.Model(&models.User{}).Select("token_version").Where("id = ?", user.ID).Scan(&user.TokenVersion).Error; err != nil {
+		return err
+	}
+	return r.db.Model(user).Association("Roles").Find(&user.Roles)
+}
+
+// HasPermission reports whether any role of the user grants the permission.
+func (r *RoleRepository) HasPermission(userID uint, permission string) (bool, error) {
+	var count int64
+	err := r.db.Table("users_roles").
+		Joins("JOIN roles_permissions ON roles_permissions.role_id = users_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = roles_permissions.permission_id").
+		Where("users_roles.user_id = ? AND permissions.name = ?", userID, permission).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func sessionFromDbResponse(fetchedSession *models.Session, response *gorm.DB) (*models.Session, error) {
+	if response.Error != nil {
+		if errors.Is(response.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, response.Error
+	}
+	return fetchedSession, nil
+}
+
+func (r *SessionRepository) CreateSession(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *SessionRepository) FindByID(id uint) (*models.Session, error) {
+	var session models.Session
+	return sessionFromDbResponse(&session, r.db.First(&session, id))
+}
+
+func (r *SessionRepository) FindByRefreshTokenHash(hash string) (*models.Session, error) {
+	var session models.Session
+	return sessionFromDbResponse(&session, r.db.Where("refresh_token_hash = ?", hash).First(&session))
+}
+
+// FindByRotatedTokenHash returns the session which once used the given refresh token
+// but has already rotated it.
+func (r *SessionRepository) FindByRotatedTokenHash(hash string) (*models.Session, error) {
+	var rotated models.RotatedRefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&rotated).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r.FindByID(rotated.SessionID)
+}
+
+// RotateRefreshToken replaces the session refresh token with newHash and remembers the old one.
+// rotated is false when the token was rotated concurrently, so the caller lost the race.
+func (r *SessionRepository) RotateRefreshToken(session *models.Session, newHash string, expiresAt time.Time) (rotated bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// the conditional update goes first, so only the winner of a race remembers the old token
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND refresh_token_hash = ?", session.ID, session.RefreshTokenHash).
+			Updates(map[string]interface{}{
+				"refresh_token_hash": newHash,
+				"expires_at":         expiresAt,
+				"last_seen_at":       time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Create(&models.RotatedRefreshToken{
+			SessionID: session.ID,
+			TokenHash: session.RefreshTokenHash,
+		}).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	if err != nil || !rotated {
+		return false, err
+	}
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+// ListActiveSessions returns sessions which are neither revoked nor expired, the most recently used first.
+func (r *SessionRepository) ListActiveSessions(userID uint, now time.Time) ([]models.Session, error) {
+	sessions := []models.Session{}
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC, id DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchSession moves the last seen time of the session forward.
+func (r *SessionRepository) TouchSession(id uint, seenAt time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, seenAt).
+		Update("last_seen_at", seenAt).Error
+}
+
+func (r *SessionRepository) RevokeSession(id uint) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *SessionRepository) RevokeAllUserSessions(userID uint) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOtherUserSessions revokes every session of the user except keepID and returns how many were revoked.
+func (r *SessionRepository) RevokeOtherUserSessions(userID, keepID uint) (int64, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"errors"
+	"social-network/user-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+func (r *TwoFactorRepository) FindByUserID(userID uint) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	if err := r.db.Where("user_id = ?", userID).First(&twoFactor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// SaveEnrollment replaces a previous unconfirmed enrollment of the user.
+func (r *TwoFactorRepository) SaveEnrollment(twoFactor *models.TwoFactor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", twoFactor.UserID).Delete(&models.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(twoFactor).Error
+	})
+}
+
+// Enable confirms the enrollment, stores the recovery codes and turns 2FA on for the user.
+func (r *TwoFactorRepository) Enable(twoFactor *models.TwoFactor, recoveryCodeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		twoFactor.Confirmed = true
+		if err := tx.Save(twoFactor).Error; err != nil {
+			return err
+		}
+		if err := replaceRecoveryCodes(tx, twoFactor.UserID, recoveryCodeHashes); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", twoFactor.UserID).
+			Update("two_factor_enabled", true).Error
+	})
+}
+
+func (r *TwoFactorRepository) Disable(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("two_factor_enabled", false).Error
+	})
+}
+
+// UseStep remembers the last accepted TOTP step. It returns false if the same
+// or a later step was already used concurrently.
+func (r *TwoFactorRepository) UseStep(twoFactor *models.TwoFactor, step int64) (bool, error) {
+	result := r.db.Model(&models.TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", twoFactor.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	twoFactor.LastUsedStep = step
+	return result.RowsAffected == 1, nil
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, len(codeHashes))
+	for i, codeHash := range codeHashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: codeHash}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode returns false if there is no unused code with the hash.
+func (r *TwoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *TwoFactorRepository) CreateChallenge(challenge *models.LoginChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *TwoFactorRepository) FindChallengeByHash(hash string) (*models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+	if err := r.db.Where("token_hash = ?", hash).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *TwoFactorRepository) IncrementChallengeAttempts(challenge *models.LoginChallenge) error {
+	challenge.Attempts++
+	return r.db.Model(challenge).Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// UseChallenge returns false if the challenge was already used by a concurrent request.
+func (r *TwoFactorRepository) UseChallenge(challenge *models.LoginChallenge, now time.Time) (bool, error) {
+	result := r.db.Model(&models.LoginChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	challenge.UsedAt = &now
+	return result.RowsAffected == 1, nil
+}
@@ -2,25 +2,28 @@ package repositories
 
 import (
 	"errors"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"social-network/user-service/models"
+	"social-network/user-service/passwords"
+	"strings"
 )
 
 type UserRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	hasher *passwords.Hasher
 }
 
-func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *gorm.DB, hasher *passwords.Hasher) *UserRepository {
+	return &UserRepository{db: db, hasher: hasher}
 }
 
 func (r *UserRepository) CreateUser(user *models.User) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := r.hasher.Hash(user.Password)
 	if err != nil {
 		return err
 	}
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
 	return r.db.Create(user).Error
 }
@@ -37,19 +40,158 @@ func userFromDbResponse(fetchedUser *models.User, response *gorm.DB) (*models.Us
 
 func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 	var user models.User
-	return userFromDbResponse(&user, r.db.Where(&models.User{Username: username}).First(&user))
+	return userFromDbResponse(&user, r.db.Preload("Roles").Where(&models.User{Username: username}).First(&user))
 }
 
 func (r *UserRepository) FindByID(id uint) (*models.User, error) {
 	var user models.User
-	return userFromDbResponse(&user, r.db.First(&user, id))
+	return userFromDbResponse(&user, r.db.Preload("Roles").First(&user, id))
+}
+
+// FindByIDs returns the existing users among ids, ordered by id.
+func (r *UserRepository) FindByIDs(ids []uint) ([]models.User, error) {
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Order("id").Find(&users).Error
+	return users, err
 }
 
 func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	return userFromDbResponse(&user, r.db.Where(&models.User{Email: email}).First(&user))
+	return userFromDbResponse(&user, r.db.Preload("Roles").Where(&models.User{Email: email}).First(&user))
 }
 
 func (r *UserRepository) UpdateUser(user *models.User) error {
 	return r.db.Save(user).Error
 }
+
+// CheckPassword compares the password with the stored hash, needsRehash is set on a match
+// if the hash was made by a legacy scheme or with outdated parameters.
+func (r *UserRepository) CheckPassword(user *models.User, password string) (ok bool, needsRehash bool, err error) {
+	return r.hasher.Verify(password, user.Password)
+}
+
+// RehashPassword stores the hash of the current scheme for an already verified password.
+// Unlike UpdatePassword it keeps the token version, the password itself doesn't change.
+func (r *UserRepository) RehashPassword(user *models.User, password string) error {
+	hashedPassword, err := r.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	// compared with the old hash, so a password changed in the meantime isn't overwritten
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		Update("password", hashedPassword)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		user.Password = hashedPassword
+	}
+	return nil
+}
+
+func (r *UserRepository) UpdatePassword(user *models.User, password string) error {
+	hashedPassword, err := r.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+
+	// incremented in the database, so concurrent password changes can't end up with the same version
+	err = r.db.Model(user).Updates(map[string]interface{}{
+		"password":      user.Password,
+		"token_version": gorm.Expr("token_version + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.User{}).Select("token_version").Where("id = ?", user.ID).Scan(&user.TokenVersion).Error
+}
+
+// UpdateAvatar replaces the avatar version, an empty version removes the avatar.
+func (r *UserRepository) UpdateAvatar(user *models.User, version string) error {
+	if err := r.db.Model(user).Update("avatar_version", version).Error; err != nil {
+		return err
+	}
+	user.AvatarURLs = models.AvatarURLs(user.ID, version)
+	return nil
+}
+
+// FindTokenVersion returns the current token version of the user, ok is false if the user doesn't exist.
+func (r *UserRepository) FindTokenVersion(userID uint) (version uint, ok bool, err error) {
+	var versions []uint
+	err = r.db.Model(&models.User{}).Where("id = ?", userID).Limit(1).Pluck("token_version", &versions).Error
+	if err != nil || len(versions) == 0 {
+		return 0, false, err
+	}
+	return versions[0], true, nil
+}
+
+// EnsureSearchIndexes creates indexes used by SearchUsers. Postgres gets trigram indexes,
+// which serve both prefix and substring LIKE queries, other databases get plain ones.
+func (r *UserRepository) EnsureSearchIndexes() error {
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username))",
+		"CREATE INDEX IF NOT EXISTS idx_users_first_name_lower ON users (LOWER(first_name))",
+		"CREATE INDEX IF NOT EXISTS idx_users_last_name_lower ON users (LOWER(last_name))",
+		"CREATE INDEX IF NOT EXISTS idx_users_display_name_lower ON users (LOWER(display_name))",
+	}
+	if r.db.Dialector.Name() == "postgres" {
+		statements = []string{
+			"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+			"CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (LOWER(username) gin_trgm_ops)",
+			"CREATE INDEX IF NOT EXISTS idx_users_first_name_trgm ON users USING gin (LOWER(first_name) gin_trgm_ops)",
+			"CREATE INDEX IF NOT EXISTS idx_users_last_name_trgm ON users USING gin (LOWER(last_name) gin_trgm_ops)",
+			"CREATE INDEX IF NOT EXISTS idx_users_display_name_trgm ON users USING gin (LOWER(display_name) gin_trgm_ops)",
+		}
+	}
+	for _, statement := range statements {
+		if err := r.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchUsers does a case-insensitive substring search over username, first, last and display name.
+// Exact username matches go first, then username prefixes, then name prefixes, then the rest.
+func (r *UserRepository) SearchUsers(search string, viewerID uint, page, pageSize int) ([]models.User, int64, error) {
+	search = strings.ToLower(search)
+	contains := "%" + escapeLike(search) + "%"
+	prefix := escapeLike(search) + "%"
+
+	query := r.db.Model(&models.User{}).Scopes(visibleTo(viewerID)).Where(
+		`LOWER(username) LIKE ? ESCAPE '\' OR LOWER(first_name) LIKE ? ESCAPE '\' OR LOWER(last_name) LIKE ? ESCAPE '\'
+			OR LOWER(display_name) LIKE ? ESCAPE '\'`,
+		contains, contains, contains, contains)
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rank := clause.Expr{
+		SQL: `CASE
+			WHEN LOWER(username) = ? THEN 0
+			WHEN LOWER(username) LIKE ? ESCAPE '\' THEN 1
+			WHEN LOWER(first_name) LIKE ? ESCAPE '\' OR LOWER(last_name) LIKE ? ESCAPE '\'
+				OR LOWER(display_name) LIKE ? ESCAPE '\' THEN 2
+			ELSE 3 END, username`,
+		Vars: []interface{}{search, prefix, prefix, prefix, prefix},
+	}
+	var users []models.User
+	err := query.
+		Clauses(clause.OrderBy{Expression: rank}).
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, count, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
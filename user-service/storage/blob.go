@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps binary objects such as avatars. Keys are slash separated paths,
+// so a whole user's data can be removed by prefix.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data io.Reader) error
+	// Get returns ErrBlobNotFound if there is no blob with the key, the caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createAccessToken(t *testing.T, env *testEnv, request contracts.CreateAccessTokenRequest) contracts.CreateAccessTokenResponse {
+	w := postJSON(env.router, "/api/users/tokens", request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response contracts.CreateAccessTokenResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := postJSON(env.router, "/api/users/tokens", contracts.CreateAccessTokenRequest{
+		Name:   "bot",
+		Scopes: []string{"admin"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	created := createAccessToken(t, env, contracts.CreateAccessTokenRequest{
+		Name:   "bot",
+		Scopes: []string{models.ScopePostsRead, models.ScopePostsRead},
+	})
+	assert.True(t, strings.HasPrefix(created.Token, "snpat_"))
+	assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+	assert.Equal(t, []string{models.ScopePostsRead}, created.Scopes)
+	assert.Nil(t, created.ExpiresAt)
+
+	var list contracts.ListAccessTokensResponse
+	w = getJSON(t, env.router, "/api/users/tokens", &list)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Token)
+	if assert.Len(t, list.Tokens, 1) {
+		assert.Equal(t, "bot", list.Tokens[0].Name)
+	}
+
+	introspection := introspect(t, env, created.Token)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, contracts.TokenTypePersonalAccess, introspection.TokenType)
+	assert.Equal(t, uint(1), introspection.UserID)
+	assert.Equal(t, []string{models.ScopePostsRead}, introspection.Scopes)
+	assert.Empty(t, introspection.Roles)
+
+	// user-service itself accepts only session tokens
+	makeAdmin(t, env, 1)
+	w = authorizedRequest(env.router, "GET", "/api/admin/roles", created.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/tokens/%d", created.ID), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, introspect(t, env, created.Token).Active)
+	w = authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/tokens/%d", created.ID), "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	getJSON(t, env.router, "/api/users/tokens", &list)
+	assert.Empty(t, list.Tokens)
+}
+
+func TestPersonalAccessTokenExpiry(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	days := 1
+	created := createAccessToken(t, env, contracts.CreateAccessTokenRequest{
+		Name:          "script",
+		Scopes:        []string{models.ScopePostsRead, models.ScopePostsWrite},
+		ExpiresInDays: &days,
+	})
+	assert.NotNil(t, created.ExpiresAt)
+	assert.True(t, introspect(t, env, created.Token).Active)
+
+	env.accessTokens.Now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	assert.False(t, introspect(t, env, created.Token).Active)
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleAndCancelAccountDeletion(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "DELETE", "/api/users/profile", "",
+		contracts.DeleteAccountRequest{Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var deletion models.AccountDeletion
+	w = getJSON(t, env.router, "/api/users/profile/deletion", &deletion)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/profile", "",
+		contracts.DeleteAccountRequest{Password: "password"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	w = authorizedRequest(env.router, "DELETE", "/api/users/profile", "",
+		contracts.DeleteAccountRequest{Password: "password"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = getJSON(t, env.router, "/api/users/profile/deletion", &deletion)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(1), deletion.UserID)
+	assert.Equal(t, 24*time.Hour, deletion.ScheduledFor.Sub(deletion.RequestedAt))
+
+	w = postJSON(env.router, "/api/users/profile/deletion/cancel", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(env.router, "/api/users/profile/deletion/cancel", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	events := listOwnAuditEvents(t, env).Events
+	assert.Equal(t, []string{models.AuditDeletionCanceled, models.AuditDeletionScheduled, models.AuditRegistered},
+		eventTypes(events))
+	assert.Equal(t, "scheduled for "+deletion.ScheduledFor.UTC().Format(time.RFC3339), events[1].Details)
+
+	// a canceled deletion is never processed
+	env.deleter.Now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	completed, err := env.deleter.ProcessDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, completed)
+	user, _ := env.userRepo.FindByID(1)
+	assert.NotNil(t, user)
+}
+
+func TestAccountErasedAfterGracePeriod(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	invite := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 1})
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "other", invite.Code).Code)
+	_, err := env.followRepo.Follow(1, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, env.relationRepo.Mute(2, 1))
+	env.posts.affected = 3
+
+	w := authorizedRequest(env.router, "DELETE", "/api/users/profile", "",
+		contracts.DeleteAccountRequest{Password: "password"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	completed, err := env.deleter.ProcessDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, completed)
+	assert.Empty(t, env.posts.requests)
+
+	now := time.Now().Add(25 * time.Hour)
+	env.deleter.Now = func() time.Time { return now }
+	completed, err = env.deleter.ProcessDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, completed)
+
+	assert.Len(t, env.posts.requests, 1)
+	assert.Equal(t, "1", env.posts.requests[0].CreatorId)
+	assert.False(t, env.posts.requests[0].Anonymize)
+
+	user, _ := env.userRepo.FindByID(1)
+	assert.Nil(t, user)
+	var rows int64
+	env.db.Unscoped().Model(&models.User{}).Where("id = ?", 1).Count(&rows)
+	assert.Zero(t, rows, "the user row must be hard deleted")
+	env.db.Model(&models.Session{}).Where("user_id = ?", 1).Count(&rows)
+	assert.Zero(t, rows)
+	env.db.Model(&models.Follow{}).Where("follower_id = ? OR followee_id = ?", 1, 1).Count(&rows)
+	assert.Zero(t, rows)
+	env.db.Model(&models.Mute{}).Where("muter_id = ? OR muted_id = ?", 1, 1).Count(&rows)
+	assert.Zero(t, rows)
+	env.db.Model(&models.InviteCode{}).Where("created_by_id = ?", 1).Count(&rows)
+	assert.Zero(t, rows)
+
+	var deletion models.AccountDeletion
+	assert.NoError(t, env.db.Where("user_id = ?", 1).First(&deletion).Error)
+	assert.NotNil(t, deletion.CompletedAt)
+	assert.Equal(t, int64(3), deletion.PostsErased)
+
+	other, _ := env.userRepo.FindByID(2)
+	assert.NotNil(t, other)
+	assert.Nil(t, other.InvitedByID)
+}
+
+func TestAccountDeletionRetriedWhenPostServiceFails(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	w := authorizedRequest(env.router, "DELETE", "/api/users/profile", "",
+		contracts.DeleteAccountRequest{Password: "password"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	env.deleter.Now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	env.posts.err = errors.New("unavailable")
+	completed, err := env.deleter.ProcessDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, completed)
+
+	user, _ := env.userRepo.FindByID(1)
+	assert.NotNil(t, user, "the account must stay until the posts are erased")
+	var deletion models.AccountDeletion
+	assert.NoError(t, env.db.Where("user_id = ?", 1).First(&deletion).Error)
+	assert.Equal(t, 1, deletion.Attempts)
+	assert.Contains(t, deletion.LastError, "unavailable")
+
+	env.posts.err = nil
+	completed, err = env.deleter.ProcessDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, completed)
+	user, _ = env.userRepo.FindByID(1)
+	assert.Nil(t, user)
+}
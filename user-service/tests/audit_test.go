@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func listOwnAuditEvents(t *testing.T, env *testEnv) contracts.ListAuditEventsResponse {
+	var response contracts.ListAuditEventsResponse
+	w := getJSON(t, env.router, "/api/users/security-events?pageSize=100", &response)
+	assert.Equal(t, http.StatusOK, w.Code)
+	return response
+}
+
+func eventTypes(events []models.AuditEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestAuditLogRecordsAccountEvents(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	registerUser(t, env.router, "other")
+
+	body, _ := json.Marshal(contracts.LoginRequest{Username: "user", Password: "wrong"})
+	req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "suspicious-client/1.0")
+	req.RemoteAddr = "203.0.113.7:51234"
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	// unknown usernames don't create events
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "nobody", Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		FirstName: "Ivan",
+		Email:     "new@email.com",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	// nothing changes, so nothing is recorded
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{FirstName: "Ivan"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	token := createAccessToken(t, env, contracts.CreateAccessTokenRequest{
+		Name: "bot", Scopes: []string{models.ScopePostsRead}})
+	w = authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/tokens/%d", token.ID), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(env.router, "/api/auth/logout", contracts.LogoutRequest{RefreshToken: registered.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = authorizedRequest(env.router, "PUT", "/api/users/password", "", contracts.ChangePasswordRequest{
+		CurrentPassword: "password",
+		NewPassword:     "new-password",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	events := listOwnAuditEvents(t, env)
+	assert.Equal(t, []string{
+		models.AuditPasswordChanged,
+		models.AuditSessionRevoked,
+		models.AuditAccessTokenRevoked,
+		models.AuditEmailChanged,
+		models.AuditProfileUpdated,
+		models.AuditLoginSucceeded,
+		models.AuditLoginFailed,
+		models.AuditRegistered,
+	}, eventTypes(events.Events))
+	assert.Equal(t, int64(8), events.TotalCount)
+
+	failed := events.Events[6]
+	assert.Equal(t, "suspicious-client/1.0", failed.UserAgent)
+	assert.Equal(t, "203.0.113.7", failed.IP)
+	assert.False(t, failed.CreatedAt.IsZero())
+	assert.Equal(t, "first_name", events.Events[4].Details)
+	assert.Equal(t, "user@email.com -> new@email.com", events.Events[3].Details)
+	for _, event := range events.Events {
+		assert.Equal(t, uint(1), event.UserID)
+	}
+}
+
+func TestAdminAuditLogQuery(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+	for i := 0; i < 3; i++ {
+		postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "wrong"})
+	}
+
+	w := authorizedRequest(env.router, "GET", "/api/admin/security-events", registerUser(t, env.router, "other").JwtToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	query := func(params url.Values) contracts.ListAuditEventsResponse {
+		w := authorizedRequest(env.router, "GET", "/api/admin/security-events?"+params.Encode(), adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response contracts.ListAuditEventsResponse
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	all := query(url.Values{})
+	assert.Equal(t, int64(6), all.TotalCount)
+	byUser := query(url.Values{"userId": {"1"}})
+	assert.Equal(t, int64(4), byUser.TotalCount)
+	failures := query(url.Values{"userId": {"1"}, "type": {models.AuditLoginFailed}, "pageSize": {"2"}})
+	assert.Equal(t, int64(3), failures.TotalCount)
+	assert.Len(t, failures.Events, 2)
+	secondPage := query(url.Values{"type": {models.AuditLoginFailed}, "page": {"2"}, "pageSize": {"2"}})
+	assert.Len(t, secondPage.Events, 1)
+
+	from := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	to := time.Now().Add(time.Hour).Format(time.RFC3339)
+	assert.Equal(t, int64(6), query(url.Values{"from": {from}, "to": {to}}).TotalCount)
+	assert.Equal(t, int64(0), query(url.Values{"from": {to}}).TotalCount)
+	assert.Equal(t, int64(0), query(url.Values{"to": {from}}).TotalCount)
+
+	for _, params := range []url.Values{
+		{"userId": {"abc"}},
+		{"type": {"unknown"}},
+		{"from": {"yesterday"}},
+		{"from": {to}, "to": {from}},
+	} {
+		w = authorizedRequest(env.router, "GET", "/api/admin/security-events?"+params.Encode(), adminToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, params.Encode())
+	}
+}
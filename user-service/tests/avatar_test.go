@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"social-network/user-service/contracts"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func uploadAvatar(router *gin.Engine, contentType string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar"`)
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("PUT", "/api/users/profile/avatar", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// testJPEGWithExif inserts an APP1 segment with EXIF data right after the start of image marker.
+func testJPEGWithExif(t *testing.T) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 80, 120)), nil))
+	payload := append([]byte("Exif\x00\x00"), []byte("GPS secret location")...)
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func getAvatar(router *gin.Engine, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadAvatar(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := uploadAvatar(env.router, "image/png", testPNG(t, 300, 200))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response contracts.AvatarResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.AvatarURLs, 3)
+
+	for size, expected := range map[string]int{"64": 64, "128": 128, "256": 256} {
+		w = getAvatar(env.router, response.AvatarURLs[size])
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+		thumbnail, err := jpeg.Decode(w.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, expected, expected), thumbnail.Bounds())
+	}
+
+	var profile map[string]interface{}
+	w = authorizedRequest(env.router, "GET", "/api/users/profile", "", nil)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &profile))
+	assert.Equal(t, response.AvatarURLs["128"], profile["avatar_urls"].(map[string]interface{})["128"])
+	_, publicProfile := getPublicProfile(t, env.router, "/api/users/1")
+	assert.Contains(t, publicProfile, "avatar_urls")
+}
+
+func TestUploadAvatarStripsMetadata(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	content := testJPEGWithExif(t)
+	_, err := jpeg.Decode(bytes.NewReader(content))
+	assert.NoError(t, err)
+	w := uploadAvatar(env.router, "image/jpeg", content)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response contracts.AvatarResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	w = getAvatar(env.router, response.AvatarURLs["256"])
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "Exif")
+	assert.NotContains(t, w.Body.String(), "GPS secret location")
+}
+
+func TestUploadAvatarValidation(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := uploadAvatar(env.router, "text/plain", []byte("not an image at all"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w = uploadAvatar(env.router, "image/jpeg", testPNG(t, 10, 10))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w = uploadAvatar(env.router, "image/png", testPNG(t, 10, 10)[:60])
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = uploadAvatar(env.router, "image/png", append(testPNG(t, 10, 10), make([]byte, 1<<20)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req, _ := http.NewRequest("PUT", "/api/users/profile/avatar", nil)
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	user, _ := env.userRepo.FindByID(1)
+	assert.Empty(t, user.AvatarVersion)
+	assert.Nil(t, user.AvatarURLs)
+}
+
+func TestReplaceAndDeleteAvatar(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "DELETE", "/api/users/profile/avatar", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var first, second contracts.AvatarResponse
+	w = uploadAvatar(env.router, "image/png", testPNG(t, 50, 50))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &first))
+	w = uploadAvatar(env.router, "image/png", testPNG(t, 60, 40))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &second))
+	assert.NotEqual(t, first.AvatarURLs["64"], second.AvatarURLs["64"])
+	assert.Equal(t, http.StatusNotFound, getAvatar(env.router, first.AvatarURLs["64"]).Code)
+	assert.Equal(t, http.StatusOK, getAvatar(env.router, second.AvatarURLs["64"]).Code)
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/profile/avatar", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, getAvatar(env.router, second.AvatarURLs["64"]).Code)
+	user, _ := env.userRepo.FindByID(1)
+	assert.Nil(t, user.AvatarURLs)
+
+	assert.Equal(t, http.StatusNotFound, getAvatar(env.router, "/api/users/avatars/1/..%2F..%2Fsecret").Code)
+	assert.Equal(t, http.StatusNotFound, getAvatar(env.router, "/api/users/avatars/abc/0000000000000000-64.jpg").Code)
+}
+
+func TestAccountDeletionErasesAvatars(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	w := uploadAvatar(env.router, "image/png", testPNG(t, 50, 50))
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err := os.Stat(filepath.Join(env.avatarsDir, "avatars", "1"))
+	assert.NoError(t, err)
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/profile", "",
+		contracts.DeleteAccountRequest{Password: "password"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	env.deleter.Now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	completed, err := env.deleter.ProcessDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, completed)
+
+	_, err = os.Stat(filepath.Join(env.avatarsDir, "avatars", "1"))
+	assert.True(t, os.IsNotExist(err))
+}
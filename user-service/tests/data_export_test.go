@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/user-service/contracts"
+	"social-network/user-service/middleware"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getInternalJSON(t *testing.T, router *gin.Engine, path, token string, response interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	if token != "" {
+		req.Header.Set(middleware.InternalTokenHeader, token)
+	}
+	router.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+	}
+	return w
+}
+
+func TestExportUserData(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	registerUser(t, env.router, "friend")
+	registerUser(t, env.router, "other")
+	_, err := env.followRepo.Follow(1, 2)
+	assert.NoError(t, err)
+	_, err = env.followRepo.Follow(2, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, env.relationRepo.Block(1, 3))
+	assert.NoError(t, env.relationRepo.Mute(1, 2))
+	// blocks made by other users are their data, not this user's
+	assert.NoError(t, env.relationRepo.Block(3, 2))
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var export contracts.UserDataExport
+	w = getInternalJSON(t, env.router, "/internal/users/1/export", testInternalToken, &export)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+
+	assert.Equal(t, "user", export.Profile.Username)
+	assert.Equal(t, "user@email.com", export.Profile.Email)
+	assert.Len(t, export.Relations.Following, 1)
+	assert.Equal(t, uint(2), export.Relations.Following[0].UserID)
+	assert.Len(t, export.Relations.Followers, 1)
+	assert.Equal(t, uint(2), export.Relations.Followers[0].UserID)
+	assert.Len(t, export.Relations.Blocked, 1)
+	assert.Equal(t, uint(3), export.Relations.Blocked[0].UserID)
+	assert.Len(t, export.Relations.Muted, 1)
+
+	assert.Len(t, export.Security.Sessions, 1)
+	assert.Len(t, export.Security.LoginFailures, 1)
+	assert.Nil(t, export.Security.TwoFactorEnabledAt)
+
+	w = getInternalJSON(t, env.router, "/internal/users/42/export", testInternalToken, &export)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestExportUserDataRequiresInternalToken(t *testing.T) {
+	env := newTestEnv()
+	user := registerUser(t, env.router, "user")
+
+	var export contracts.UserDataExport
+	w := getInternalJSON(t, env.router, "/internal/users/1/export", "", &export)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "user@email.com")
+
+	w = getInternalJSON(t, env.router, "/internal/users/1/export", "wrong-token", &export)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// a user's own access token is not enough either
+	w = authorizedRequest(env.router, "GET", "/internal/users/1/export", user.TokenPair.JwtToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerification(t *testing.T) {
+	env := newTestEnv()
+	auth := registerUser(t, env.router, "user")
+	assert.False(t, auth.User.EmailVerified)
+	assert.False(t, introspect(t, env, auth.JwtToken).EmailVerified)
+
+	token := lastNotificationData(t, env, "email_verification_token")
+	assert.NotEmpty(t, token)
+
+	w := postJSON(env.router, "/api/auth/verify-email", contracts.VerifyEmailRequest{Token: token})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var user models.User
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.True(t, user.EmailVerified)
+	assert.True(t, introspect(t, env, auth.JwtToken).EmailVerified)
+
+	// the token is single-use
+	w = postJSON(env.router, "/api/auth/verify-email", contracts.VerifyEmailRequest{Token: token})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// already verified
+	w = postJSON(env.router, "/api/users/verify-email/resend", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestEmailChangeRequiresVerification(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	oldToken := lastNotificationData(t, env, "email_verification_token")
+
+	requestBody, _ := json.Marshal(contracts.UpdateProfileRequest{Email: "new@email.com"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/api/users/profile", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the token sent to the old address can't verify the new one
+	w = postJSON(env.router, "/api/auth/verify-email", contracts.VerifyEmailRequest{Token: oldToken})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	messages, _ := env.notifier.ReadMessages()
+	assert.Equal(t, "new@email.com", messages[len(messages)-1].To)
+
+	w = postJSON(env.router, "/api/users/verify-email/resend", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	newToken := lastNotificationData(t, env, "email_verification_token")
+
+	w = postJSON(env.router, "/api/auth/verify-email", contracts.VerifyEmailRequest{Token: newToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	user, _ := env.userRepo.FindByID(1)
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, "new@email.com", user.Email)
+
+	// changing the email again resets the state
+	requestBody, _ = json.Marshal(contracts.UpdateProfileRequest{Email: "newer@email.com"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/api/users/profile", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	user, _ = env.userRepo.FindByID(1)
+	assert.False(t, user.EmailVerified)
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/common/proto"
+	"social-network/user-service/contracts"
+	"social-network/user-service/handlers"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getJSON(t *testing.T, router *gin.Engine, path string, response interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+	}
+	return w
+}
+
+func TestFollowUnfollow(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	registerUser(t, env.router, "other")
+
+	w := postJSON(env.router, "/api/users/2/follow", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// following twice is not an error
+	w = postJSON(env.router, "/api/users/2/follow", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status contracts.FollowStatusResponse
+	w = getJSON(t, env.router, "/api/users/1/following/2", &status)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, status.Following)
+	w = getJSON(t, env.router, "/api/users/2/following/1", &status)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, status.Following)
+
+	var profile contracts.UserProfileResponse
+	getJSON(t, env.router, "/api/users/2", &profile)
+	assert.Equal(t, int64(1), profile.FollowersCount)
+	assert.Equal(t, int64(0), profile.FollowingCount)
+	assert.True(t, profile.IsFollowed)
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/2/follow", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	getJSON(t, env.router, "/api/users/2", &profile)
+	assert.Equal(t, int64(0), profile.FollowersCount)
+	assert.False(t, profile.IsFollowed)
+}
+
+func TestFollowValidation(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+
+	w := postJSON(env.router, "/api/users/1/follow", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(env.router, "/api/users/42/follow", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = postJSON(env.router, "/api/users/abc/follow", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListFollowers(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"star", "fan1", "fan2", "fan3"} {
+		registerUser(t, env.router, username)
+	}
+	for followerID := uint(2); followerID <= 4; followerID++ {
+		_, err := env.followRepo.Follow(followerID, 1)
+		assert.NoError(t, err)
+	}
+	_, err := env.followRepo.Follow(1, 2)
+	assert.NoError(t, err)
+
+	var followers contracts.ListUsersResponse
+	w := getJSON(t, env.router, "/api/users/1/followers?page=1&pageSize=2", &followers)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(3), followers.TotalCount)
+	assert.Len(t, followers.Users, 2)
+
+	var following contracts.ListUsersResponse
+	w = getJSON(t, env.router, "/api/users/1/following", &following)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"fan1"}, usernames(following.Users))
+
+	var profile contracts.UserProfileResponse
+	getJSON(t, env.router, "/api/users/by-username/star", &profile)
+	assert.Equal(t, int64(3), profile.FollowersCount)
+	assert.Equal(t, int64(1), profile.FollowingCount)
+}
+
+func TestFollowGRPC(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"star", "fan1", "fan2"} {
+		registerUser(t, env.router, username)
+	}
+	for followerID := uint(2); followerID <= 3; followerID++ {
+		_, err := env.followRepo.Follow(followerID, 1)
+		assert.NoError(t, err)
+	}
+	handler := handlers.NewFollowGRPCHandler(env.followRepo)
+	ctx := context.Background()
+
+	isFollowing, err := handler.IsFollowing(ctx, &proto.IsFollowingRequest{FollowerId: 2, FolloweeId: 1})
+	assert.NoError(t, err)
+	assert.True(t, isFollowing.Following)
+	isFollowing, err = handler.IsFollowing(ctx, &proto.IsFollowingRequest{FollowerId: 1, FolloweeId: 2})
+	assert.NoError(t, err)
+	assert.False(t, isFollowing.Following)
+
+	followers, err := handler.ListFollowers(ctx, &proto.ListFollowsRequest{UserId: 1, PageSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), followers.TotalCount)
+	assert.Equal(t, int32(2), followers.TotalPages)
+	assert.Len(t, followers.UserIds, 1)
+
+	following, err := handler.ListFollowing(ctx, &proto.ListFollowsRequest{UserId: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1}, following.UserIds)
+
+	counts, err := handler.GetFollowCounts(ctx, &proto.GetFollowCountsRequest{UserId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), counts.FollowersCount)
+	assert.Equal(t, int64(0), counts.FollowingCount)
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"social-network/user-service/accounts"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"social-network/user-service/repositories"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createInvite(t *testing.T, env *testEnv, request contracts.CreateInviteRequest) contracts.CreateInviteResponse {
+	w := authorizedRequest(env.router, "POST", "/api/users/invites", "", request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response contracts.CreateInviteResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func registerWithInvite(env *testEnv, username, code string) *httptest.ResponseRecorder {
+	return postJSON(env.router, "/api/auth/register", contracts.RegisterRequest{
+		Username:   username,
+		Email:      username + "@email.com",
+		Password:   "password",
+		InviteCode: code,
+	})
+}
+
+func listInvites(t *testing.T, env *testEnv) []contracts.Invite {
+	var response contracts.ListInvitesResponse
+	w := getJSON(t, env.router, "/api/users/invites", &response)
+	assert.Equal(t, http.StatusOK, w.Code)
+	return response.Invites
+}
+
+func TestOpenRegistrationAcceptsInvites(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "inviter")
+	invite := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 1})
+
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "open", "").Code)
+	assert.Equal(t, http.StatusForbidden, registerWithInvite(env, "typo", invite.Code+"x").Code)
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "invited", invite.Code).Code)
+
+	invited, _ := env.userRepo.FindByUsername("invited")
+	assert.Equal(t, uint(1), *invited.InvitedByID)
+	open, _ := env.userRepo.FindByUsername("open")
+	assert.Nil(t, open.InvitedByID)
+}
+
+func TestInviteOnlyRegistration(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "inviter")
+	env.inviter.InviteOnly = true
+
+	w := registerWithInvite(env, "uninvited", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Registration requires an invite code")
+
+	invite := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 2})
+	assert.True(t, strings.HasPrefix(invite.Code, accounts.InviteCodePrefix))
+	assert.True(t, strings.HasPrefix(invite.Code, invite.Prefix))
+	assert.True(t, invite.Active)
+
+	// a registration rejected for another reason doesn't use up the code
+	assert.Equal(t, http.StatusBadRequest, registerWithInvite(env, "inviter", invite.Code).Code)
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "first", invite.Code).Code)
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "second", invite.Code).Code)
+	w = registerWithInvite(env, "third", invite.Code)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid or expired invite code")
+
+	invites := listInvites(t, env)
+	assert.Len(t, invites, 1)
+	assert.Equal(t, 2, invites[0].UsedCount)
+	assert.False(t, invites[0].Active)
+	assert.Equal(t, []string{"first", "second"},
+		[]string{invites[0].Invitees[0].Username, invites[0].Invitees[1].Username})
+
+	events, _, err := env.audit.ListEvents(repositories.AuditFilter{UserID: 2, Type: models.AuditRegistered}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "invited by user 1", events[0].Details)
+}
+
+func TestInviteExpiryAndRevocation(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "inviter")
+	env.inviter.InviteOnly = true
+	days := 1
+	expiring := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 5, ExpiresInDays: &days})
+	assert.NotNil(t, expiring.ExpiresAt)
+	revoked := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 5})
+
+	w := authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/invites/%d", revoked.ID), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/invites/%d", revoked.ID), "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = authorizedRequest(env.router, "DELETE", "/api/users/invites/abc", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusForbidden, registerWithInvite(env, "late", revoked.Code).Code)
+
+	env.inviter.Now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	assert.Equal(t, http.StatusForbidden, registerWithInvite(env, "late", expiring.Code).Code)
+	for _, invite := range listInvites(t, env) {
+		assert.False(t, invite.Active)
+	}
+	env.inviter.Now = time.Now
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "early", expiring.Code).Code)
+}
+
+func TestInviteLimits(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "POST", "/api/users/invites", "",
+		contracts.CreateInviteRequest{MaxUses: accounts.UserInviteMaxUses + 1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = authorizedRequest(env.router, "POST", "/api/users/invites", "", contracts.CreateInviteRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	makeAdmin(t, env, 1)
+	invite := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 500})
+	assert.Equal(t, 500, invite.MaxUses)
+}
+
+func TestAdminSeesWhoInvitedWhom(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "inviter")
+	invite := createInvite(t, env, contracts.CreateInviteRequest{MaxUses: 3})
+	assert.Equal(t, http.StatusCreated, registerWithInvite(env, "invited", invite.Code).Code)
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+
+	w := authorizedRequest(env.router, "GET", "/api/admin/users/1/invites", registerUser(t, env.router, "other").JwtToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var inviter contracts.UserInvitesResponse
+	w = authorizedRequest(env.router, "GET", "/api/admin/users/1/invites", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &inviter))
+	assert.Nil(t, inviter.InvitedByID)
+	assert.Len(t, inviter.Invites, 1)
+	assert.Equal(t, "invited", inviter.Invites[0].Invitees[0].Username)
+
+	var invited contracts.UserInvitesResponse
+	w = authorizedRequest(env.router, "GET", "/api/admin/users/2/invites", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &invited))
+	assert.Equal(t, uint(1), *invited.InvitedByID)
+	assert.Empty(t, invited.Invites)
+}
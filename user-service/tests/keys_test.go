@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"social-network/common/jwks"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func tokenHeader(t *testing.T, token string) map[string]interface{} {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &contracts.Claims{})
+	assert.Nil(t, err)
+	return parsed.Header
+}
+
+func TestJWKSPublishesSigningKey(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+
+	var set jwks.Set
+	w := getJSON(t, env.router, "/.well-known/jwks.json", &set)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, set.Keys, 1)
+
+	header := tokenHeader(t, registered.JwtToken)
+	assert.Equal(t, jwks.AlgorithmEdDSA, header["alg"])
+	key := set.Find(header["kid"].(string))
+	if assert.NotNil(t, key) {
+		publicKey, err := key.PublicKey()
+		assert.Nil(t, err)
+		_, err = jwt.ParseWithClaims(registered.JwtToken, &contracts.Claims{}, func(*jwt.Token) (interface{}, error) {
+			return publicKey, nil
+		})
+		assert.Nil(t, err)
+	}
+}
+
+func TestKeyRotationKeepsOldKeyDuringOverlap(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	oldKid := tokenHeader(t, registered.JwtToken)["kid"]
+
+	nextKey, err := auth.GenerateSigningKey()
+	assert.Nil(t, err)
+	env.keyRing.Rotate(nextKey)
+
+	var set jwks.Set
+	getJSON(t, env.router, "/.well-known/jwks.json", &set)
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, nextKey.ID, set.Keys[0].Kid)
+	assert.True(t, introspect(t, env, registered.JwtToken).Active)
+
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var fresh contracts.AuthResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &fresh))
+	assert.Equal(t, nextKey.ID, tokenHeader(t, fresh.JwtToken)["kid"])
+
+	// the overlap is over, so the old key is gone
+	env.keyRing.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	getJSON(t, env.router, "/.well-known/jwks.json", &set)
+	assert.Len(t, set.Keys, 1)
+	assert.Nil(t, set.Find(oldKid.(string)))
+	assert.False(t, introspect(t, env, registered.JwtToken).Active)
+}
+
+func TestRSASigningKeyFromFile(t *testing.T) {
+	env := newTestEnv()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.Nil(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	key, err := auth.LoadSigningKey(keyFile)
+	assert.Nil(t, err)
+	env.keyRing.Rotate(key)
+
+	registered := registerUser(t, env.router, "user")
+	header := tokenHeader(t, registered.JwtToken)
+	assert.Equal(t, jwks.AlgorithmRS256, header["alg"])
+	assert.Equal(t, key.ID, header["kid"])
+	assert.True(t, introspect(t, env, registered.JwtToken).Active)
+}
+
+func TestTokenWithForeignAlgorithmIsRejected(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	claims := &contracts.Claims{}
+	_, _, err := jwt.NewParser().ParseUnverified(registered.JwtToken, claims)
+	assert.Nil(t, err)
+
+	// an HMAC token keyed with the public key must not pass as signed by the key pair
+	active := env.keyRing.Active()
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = active.ID
+	forgedToken, err := forged.SignedString([]byte(active.Private.Public().(ed25519.PublicKey)))
+	assert.Nil(t, err)
+	assert.False(t, introspect(t, env, forgedToken).Active)
+
+	unknownKey, err := auth.GenerateSigningKey()
+	assert.Nil(t, err)
+	foreign := jwt.NewWithClaims(unknownKey.Method, claims)
+	foreign.Header["kid"] = unknownKey.ID
+	foreignToken, err := foreign.SignedString(unknownKey.Private)
+	assert.Nil(t, err)
+	assert.False(t, introspect(t, env, foreignToken).Active)
+}
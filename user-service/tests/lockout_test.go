@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/user-service/contracts"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockout(t *testing.T) {
+	env := newTestEnv()
+	now := time.Now()
+	env.loginGuard.Now = func() time.Time { return now }
+	registerUser(t, env.router, "user")
+
+	wrongLogin := contracts.LoginRequest{Username: "user", Password: "wrong"}
+	rightLogin := contracts.LoginRequest{Username: "user", Password: "password"}
+	for i := 0; i < 3; i++ {
+		w := postJSON(env.router, "/api/auth/login", wrongLogin)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// even the right password is rejected now
+	w := postJSON(env.router, "/api/auth/login", rightLogin)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	now = now.Add(time.Minute + time.Second)
+	for i := 0; i < 3; i++ {
+		postJSON(env.router, "/api/auth/login", wrongLogin)
+	}
+	// the second lockout is longer
+	w = postJSON(env.router, "/api/auth/login", rightLogin)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"))
+
+	now = now.Add(2*time.Minute + time.Second)
+	w = postJSON(env.router, "/api/auth/login", rightLogin)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLoginSuccessResetsFailures(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	// 8 failures in total, which is still below the ip limit
+	for i := 0; i < 4; i++ {
+		postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "wrong"})
+		postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "wrong"})
+		w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestLoginIPThrottle(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	for i := 0; i < 10; i++ {
+		w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{
+			Username: "victim" + strconv.Itoa(i),
+			Password: "password",
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestAdminUnlock(t *testing.T) {
+	env := newTestEnv()
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+	user := registerUser(t, env.router, "user")
+
+	for i := 0; i < 3; i++ {
+		postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "wrong"})
+	}
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = authorizedRequest(env.router, "GET", "/api/admin/lockouts?username=user", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var lockouts contracts.ListLockoutsResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &lockouts))
+	assert.Equal(t, int64(1), lockouts.TotalCount)
+	assert.Equal(t, 3, lockouts.Lockouts[0].FailedAttempts)
+	assert.Equal(t, user.User.ID, *lockouts.Lockouts[0].UserID)
+
+	w = authorizedRequest(env.router, "POST", fmt.Sprintf("/api/admin/users/%d/unlock", user.User.ID), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = authorizedRequest(env.router, "GET", "/api/admin/lockouts?username=user", adminToken, nil)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &lockouts))
+	assert.NotNil(t, lockouts.Lockouts[0].UnlockedAt)
+	assert.Equal(t, admin.User.ID, *lockouts.Lockouts[0].UnlockedByID)
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"social-network/common/proto"
+	"social-network/user-service/contracts"
+	"social-network/user-service/handlers"
+	"social-network/user-service/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModerationRequiresAdmin(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "target")
+	user := registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/1/ban", user.JwtToken,
+		contracts.BanUserRequest{Reason: "spam"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = authorizedRequest(env.router, "GET", "/api/admin/users/1/moderation", user.JwtToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	target, _ := env.userRepo.FindByID(1)
+	assert.False(t, target.IsRestricted(time.Now()))
+}
+
+func TestSuspendBlocksLogin(t *testing.T) {
+	env := newTestEnv()
+	target := registerUser(t, env.router, "target")
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+	accessToken := createAccessToken(t, env, contracts.CreateAccessTokenRequest{
+		Name: "bot", Scopes: []string{models.ScopePostsRead}})
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/1/suspend", adminToken,
+		contracts.SuspendUserRequest{Reason: "flooding", Until: until})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response contracts.ModerationActionResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ModerationSuspend, response.Action.Action)
+	assert.Equal(t, admin.User.ID, response.Action.AdminID)
+	assert.True(t, response.Status.Suspended)
+
+	// the existing session is revoked, personal access tokens outlive it and are reported as restricted
+	w = postJSON(env.router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: target.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, introspect(t, env, target.JwtToken).Active)
+	introspection := introspect(t, env, accessToken.Token)
+	assert.True(t, introspection.Active)
+	assert.True(t, introspection.Restricted)
+	assert.False(t, introspect(t, env, adminToken).Restricted)
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "target", Password: "password"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var restricted contracts.AccountRestrictedResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &restricted))
+	assert.Equal(t, "Account is suspended", restricted.Error)
+	assert.Equal(t, "flooding", restricted.Reason)
+	assert.True(t, until.Equal(*restricted.SuspendedUntil))
+}
+
+func TestSuspensionExpires(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "target")
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/1/suspend", adminToken,
+		contracts.SuspendUserRequest{Reason: "flooding", Until: time.Now().Add(-time.Minute)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/1/suspend", adminToken,
+		contracts.SuspendUserRequest{Reason: "flooding", Until: time.Now().Add(time.Hour)})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the suspension ends by itself, the user record still holds the old end
+	target, _ := env.userRepo.FindByID(1)
+	past := time.Now().Add(-time.Minute)
+	target.SuspendedUntil = &past
+	assert.NoError(t, env.db.Save(target).Error)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "target", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/1/reinstate", adminToken,
+		contracts.ReinstateUserRequest{})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestBanAndReinstate(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "target")
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/2/ban", adminToken,
+		contracts.BanUserRequest{Reason: "self"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/1/ban", adminToken, contracts.BanUserRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/42/ban", adminToken,
+		contracts.BanUserRequest{Reason: "spam"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/1/ban", adminToken,
+		contracts.BanUserRequest{Reason: "spam"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "target", Password: "password"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Account is banned")
+
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/1/reinstate", adminToken,
+		contracts.ReinstateUserRequest{Reason: "appeal accepted"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "target", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = authorizedRequest(env.router, "GET", "/api/admin/users/1/moderation", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history contracts.UserModerationResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.False(t, history.Status.Banned)
+	assert.Equal(t, int64(2), history.TotalCount)
+	assert.Equal(t, models.ModerationReinstate, history.Actions[0].Action)
+	assert.Equal(t, models.ModerationBan, history.Actions[1].Action)
+	assert.Equal(t, "spam", history.Actions[1].Reason)
+	for _, action := range history.Actions {
+		assert.Equal(t, admin.User.ID, action.AdminID)
+	}
+}
+
+func TestReinstateWithoutBody(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "target")
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/1/ban", adminToken,
+		contracts.BanUserRequest{Reason: "spam"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/1/reinstate", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	target, _ := env.userRepo.FindByID(1)
+	assert.False(t, target.IsBanned())
+}
+
+func TestBannedLoginKeepsFailuresAndHash(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "target")
+	admin := registerUser(t, env.router, "admin")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/1/ban", adminToken,
+		contracts.BanUserRequest{Reason: "spam"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	legacyHash := setLegacyPassword(t, env, 1, "password")
+
+	login := func(password string) int {
+		return postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "target", Password: password}).Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, login("wrong"))
+	assert.Equal(t, http.StatusUnauthorized, login("wrong"))
+	assert.Equal(t, http.StatusForbidden, login("password"))
+	assert.Equal(t, legacyHash, storedPassword(t, env, 1))
+
+	// the correct password of a banned account didn't clear the earlier failures
+	assert.Equal(t, http.StatusUnauthorized, login("wrong"))
+	assert.Equal(t, http.StatusTooManyRequests, login("password"))
+}
+
+func TestListBannedUsers(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"viewer", "spammer", "admin"} {
+		registerUser(t, env.router, username)
+	}
+	adminToken := makeAdmin(t, env, 3)
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/2/ban", adminToken,
+		contracts.BanUserRequest{Reason: "spam"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	handler := handlers.NewRelationGRPCHandler(env.relationRepo, env.moderation)
+	banned, err := handler.ListBannedUsers(context.Background(), &proto.ListBannedUsersRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{2}, banned.UserIds)
+
+	w = authorizedRequest(env.router, "POST", "/api/admin/users/2/reinstate", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	banned, err = handler.ListBannedUsers(context.Background(), &proto.ListBannedUsersRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, banned.UserIds)
+}
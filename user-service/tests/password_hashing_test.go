@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/passwords"
+	"social-network/user-service/repositories"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setLegacyPassword stores a bcrypt hash like the ones made before the switch to argon2id.
+func setLegacyPassword(t *testing.T, env *testEnv, userID uint, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	assert.NoError(t, env.db.Exec("UPDATE users SET password = ? WHERE id = ?", string(hash), userID).Error)
+	return string(hash)
+}
+
+func storedPassword(t *testing.T, env *testEnv, userID uint) string {
+	user, err := env.userRepo.FindByID(userID)
+	assert.NoError(t, err)
+	return user.Password
+}
+
+func TestPasswordsHashedWithArgon2id(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	hash := storedPassword(t, env, 1)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+	parts := strings.Split(hash, "$")
+	assert.Len(t, parts, 6)
+
+	// the same password gets a different salt
+	registerUser(t, env.router, "other")
+	assert.NotEqual(t, parts[4], strings.Split(storedPassword(t, env, 2), "$")[4])
+}
+
+func TestLoginUpgradesBcryptHash(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	legacyHash := setLegacyPassword(t, env, 1, "password")
+
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, legacyHash, storedPassword(t, env, 1))
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(storedPassword(t, env, 1), "$argon2id$"))
+
+	// the password didn't change, so existing sessions stay valid
+	assert.True(t, introspect(t, env, registered.JwtToken).Active)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLegacyHashesStillVerified(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	setLegacyPassword(t, env, 1, "password")
+
+	w := authorizedRequest(env.router, "PUT", "/api/users/password", "", contracts.ChangePasswordRequest{
+		CurrentPassword: "password",
+		NewPassword:     "new-password",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(storedPassword(t, env, 1), "$argon2id$"))
+}
+
+func TestLongPasswords(t *testing.T) {
+	env := newTestEnv()
+	password := strings.Repeat("a", 100)
+	w := postJSON(env.router, "/api/auth/register", contracts.RegisterRequest{
+		Username: "user",
+		Password: password,
+		Email:    "user@email.com",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// unlike bcrypt, the bytes past 72 matter
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: password[:72]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: password})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postJSON(env.router, "/api/auth/register", contracts.RegisterRequest{
+		Username: "other",
+		Password: strings.Repeat("a", 257),
+		Email:    "other@email.com",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLegacyHashRejectsTruncatedMatch(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	password := strings.Repeat("a", 72)
+	legacyHash := setLegacyPassword(t, env, 1, password)
+
+	// bcrypt would accept it, as it ignores everything past 72 bytes
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: password + "suffix"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, legacyHash, storedPassword(t, env, 1))
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: password})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestChangedParamsTriggerRehash(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	user, _ := env.userRepo.FindByID(1)
+
+	ok, needsRehash, err := env.userRepo.CheckPassword(user, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	params := testArgon2idParams()
+	params.Iterations = 2
+	stronger := repositories.NewUserRepository(env.db, newTestHasher(params))
+	ok, needsRehash, err = stronger.CheckPassword(user, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+	_, needsRehash, _ = stronger.CheckPassword(user, "wrong")
+	assert.False(t, needsRehash)
+
+	assert.NoError(t, stronger.RehashPassword(user, "password"))
+	assert.Contains(t, storedPassword(t, env, 1), "$m=64,t=2,p=1$")
+	ok, needsRehash, err = stronger.CheckPassword(user, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+	// hashes made with the old parameters are still accepted
+	ok, _, err = env.userRepo.CheckPassword(user, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestMalformedHashes(t *testing.T) {
+	hasher := newTestHasher(testArgon2idParams())
+	for _, encoded := range []string{
+		"",
+		"plain-text",
+		"$scrypt$ln=16,r=8,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=64,t=1,p=1$not base64$aGFzaA",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdA$aGFzaA",
+	} {
+		ok, _, err := hasher.Verify("password", encoded)
+		assert.False(t, ok, encoded)
+		assert.Error(t, err, encoded)
+	}
+	_, _, err := hasher.Verify("password", "plain-text")
+	assert.ErrorIs(t, err, passwords.ErrUnknownScheme)
+}
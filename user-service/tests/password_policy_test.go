@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"social-network/user-service/contracts"
+	"social-network/user-service/passwords"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func violatedRules(t *testing.T, w *httptest.ResponseRecorder) []string {
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response contracts.PasswordPolicyErrorResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	rules := make([]string, len(response.Violations))
+	for i, violation := range response.Violations {
+		assert.NotEmpty(t, violation.Message)
+		rules[i] = violation.Rule
+	}
+	return rules
+}
+
+func TestRegisterAppliesPasswordPolicy(t *testing.T) {
+	env := newTestEnv()
+	register := func(password string) *httptest.ResponseRecorder {
+		return postJSON(env.router, "/api/auth/register", contracts.RegisterRequest{
+			Username: "johnsmith",
+			Password: password,
+			Email:    "jsmith@email.com",
+		})
+	}
+
+	assert.Equal(t, []string{passwords.RuleMinLength}, violatedRules(t, register("abc")))
+	assert.Equal(t, []string{passwords.RuleContainsUsername}, violatedRules(t, register("my-JohnSmith-password")))
+	assert.Equal(t, []string{passwords.RuleContainsEmail}, violatedRules(t, register("jsmith2024")))
+	assert.Equal(t, []string{passwords.RuleBreached}, violatedRules(t, register("iloveyou")))
+
+	user, _ := env.userRepo.FindByUsername("johnsmith")
+	assert.Nil(t, user)
+	assert.Equal(t, http.StatusCreated, register("correct horse battery staple").Code)
+}
+
+func TestPasswordChangeAppliesPolicy(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "PUT", "/api/users/password", "", contracts.ChangePasswordRequest{
+		CurrentPassword: "password",
+		NewPassword:     "qwerty123",
+	})
+	assert.Equal(t, []string{passwords.RuleBreached}, violatedRules(t, w))
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPasswordResetAppliesPolicy(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "user@email.com"})
+	resetToken := lastNotificationData(t, env, "password_reset_token")
+
+	w := postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       resetToken,
+		NewPassword: "P@ssw0rd!",
+	})
+	assert.Equal(t, []string{passwords.RuleBreached}, violatedRules(t, w))
+
+	// the rejected attempt doesn't use up the token
+	w = postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       resetToken,
+		NewPassword: "new_password",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPasswordPolicyRules(t *testing.T) {
+	policy := passwords.NewPolicy(passwords.PolicyConfig{MinLength: 10, MinCharacterClasses: 3}, nil)
+
+	rules := func(password string) []string {
+		var result []string
+		for _, violation := range policy.Check(password, "alice", "alice.w@email.com") {
+			result = append(result, violation.Rule)
+		}
+		return result
+	}
+	assert.Equal(t, []string{passwords.RuleMinLength, passwords.RuleCharacterClasses}, rules("short"))
+	assert.Equal(t, []string{passwords.RuleCharacterClasses}, rules("lowercaseonly"))
+	assert.Empty(t, rules("Mixed-case-123"))
+	// non-ASCII letters count as characters, not bytes
+	assert.Equal(t, []string{passwords.RuleMinLength}, rules("Пароль-12"))
+	assert.Equal(t, []string{passwords.RuleContainsUsername}, rules("Hi-ALICE-2024"))
+	assert.Equal(t, []string{passwords.RuleContainsUsername, passwords.RuleContainsEmail}, rules("x-Alice.W-123"))
+	// without a corpus breached passwords pass
+	assert.Empty(t, rules("P@ssw0rd!12"))
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "breached.txt")
+	content := "# sha1 of 123456 and password\n" +
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B:37359195\n" +
+		"\n" +
+		"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8\n" +
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	list, err := passwords.LoadBreachedList(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, list.Size())
+	assert.True(t, list.Contains("123456"))
+	assert.True(t, list.Contains("password"))
+	assert.False(t, list.Contains("1234567"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+	_, err = passwords.LoadBreachedList(path)
+	assert.ErrorContains(t, err, "line 1")
+	_, err = passwords.LoadBreachedList(filepath.Join(dir, "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lastNotificationData(t *testing.T, env *testEnv, key string) string {
+	messages, err := env.notifier.ReadMessages()
+	assert.Nil(t, err)
+	if !assert.NotEmpty(t, messages) {
+		return ""
+	}
+	return messages[len(messages)-1].Data[key]
+}
+
+func TestPasswordReset(t *testing.T) {
+	env := newTestEnv()
+	auth := registerUser(t, env.router, "user")
+
+	w := postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "user@email.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	resetToken := lastNotificationData(t, env, "password_reset_token")
+	assert.NotEmpty(t, resetToken)
+
+	w = postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       resetToken,
+		NewPassword: "new_password",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// previously issued tokens don't work anymore
+	assert.False(t, introspect(t, env, auth.JwtToken).Active)
+	w = postJSON(env.router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "new_password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the token is single-use
+	w = postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       resetToken,
+		NewPassword: "another_password",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPasswordResetInvalidatesOlderTokens(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "user@email.com"})
+	firstToken := lastNotificationData(t, env, "password_reset_token")
+	postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "user@email.com"})
+	secondToken := lastNotificationData(t, env, "password_reset_token")
+	assert.NotEqual(t, firstToken, secondToken)
+
+	w := postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       firstToken,
+		NewPassword: "new_password",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       secondToken,
+		NewPassword: "new_password",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	env := newTestEnv()
+
+	w := postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "nobody@email.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	messages, err := env.notifier.ReadMessages()
+	assert.Nil(t, err)
+	assert.Empty(t, messages)
+
+	w = postJSON(env.router, "/api/auth/password/reset", contracts.ResetPasswordRequest{
+		Token:       "unknown",
+		NewPassword: "new_password",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestForgotPasswordFailureLooksLikeUnknownEmail(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	unknown := postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "nobody@email.com"})
+
+	// storing the token fails only for existing accounts, so the error must not show up in the response
+	assert.NoError(t, env.db.Migrator().DropTable(&models.PasswordResetToken{}))
+	w := postJSON(env.router, "/api/auth/password/forgot", contracts.ForgotPasswordRequest{Email: "user@email.com"})
+	assert.Equal(t, unknown.Code, w.Code)
+	assert.Equal(t, unknown.Body.String(), w.Body.String())
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv()
+	auth := registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "PUT", "/api/users/password", "", contracts.ChangePasswordRequest{
+		CurrentPassword: "wrong",
+		NewPassword:     "new_password",
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, introspect(t, env, auth.JwtToken).Active)
+	versionBefore, _, err := env.userRepo.FindTokenVersion(auth.User.ID)
+	assert.NoError(t, err)
+
+	w = authorizedRequest(env.router, "PUT", "/api/users/password", "", contracts.ChangePasswordRequest{
+		CurrentPassword: "password",
+		NewPassword:     "new_password",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokens contracts.TokenPair
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	assert.False(t, introspect(t, env, auth.JwtToken).Active)
+	w = postJSON(env.router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	introspection := introspect(t, env, tokens.JwtToken)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, versionBefore+1, introspection.TokenVersion)
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "new_password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTokensIssuedBeforePasswordChangeAreRejected(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	adminToken := makeAdmin(t, env, 1)
+	w := authorizedRequest(env.router, "GET", "/api/admin/roles", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the session stays active, only the token version changes
+	user, _ := env.userRepo.FindByID(1)
+	versionBefore := user.TokenVersion
+	assert.Nil(t, env.userRepo.UpdatePassword(user, "new_password"))
+	assert.Equal(t, versionBefore+1, user.TokenVersion)
+
+	w = authorizedRequest(env.router, "GET", "/api/admin/roles", adminToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, introspect(t, env, adminToken).Active)
+
+	freshToken := makeAdmin(t, env, 1)
+	w = authorizedRequest(env.router, "GET", "/api/admin/roles", freshToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
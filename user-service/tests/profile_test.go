@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getPublicProfile(t *testing.T, router *gin.Engine, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(w, req)
+
+	var profile map[string]interface{}
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+	}
+	return w, profile
+}
+
+func TestGetUserHidesPrivateFields(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	other := registerUser(t, env.router, "other")
+
+	user, _ := env.userRepo.FindByID(other.User.ID)
+	user.PhoneNumber = "+01234567890"
+	assert.NoError(t, env.userRepo.UpdateUser(user))
+
+	w, profile := getPublicProfile(t, env.router, "/api/users/2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "other", profile["username"])
+	assert.NotContains(t, profile, "email")
+	assert.NotContains(t, profile, "phone_number")
+	assert.NotContains(t, profile, "birth_date")
+	assert.NotContains(t, w.Body.String(), "password")
+
+	user.EmailVisibility = models.VisibilityPublic
+	assert.NoError(t, env.userRepo.UpdateUser(user))
+	w, profile = getPublicProfile(t, env.router, "/api/users/by-username/other")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "other@email.com", profile["email"])
+	assert.NotContains(t, profile, "phone_number")
+}
+
+func TestGetUserOwnerSeesAllFields(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+
+	w, profile := getPublicProfile(t, env.router, "/api/users/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "viewer@email.com", profile["email"])
+}
+
+func TestUpdateProfileVisibility(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+
+	w := authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		EmailVisibility:     models.VisibilityPublic,
+		BirthDateVisibility: models.VisibilityFollowers,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	user, _ := env.userRepo.FindByID(1)
+	assert.Equal(t, models.VisibilityPublic, user.EmailVisibility)
+	assert.Equal(t, models.VisibilityFollowers, user.BirthDateVisibility)
+	assert.Equal(t, models.VisibilityPrivate, user.PhoneNumberVisibility)
+
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		PhoneNumberVisibility: "friends",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFollowersOnlyFields(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	other := registerUser(t, env.router, "other")
+	registerUser(t, env.router, "third")
+
+	user, _ := env.userRepo.FindByID(other.User.ID)
+	user.PhoneNumber = "+01234567890"
+	user.PhoneNumberVisibility = models.VisibilityFollowers
+	user.EmailVisibility = models.VisibilityPrivate
+	assert.NoError(t, env.userRepo.UpdateUser(user))
+
+	_, profile := getPublicProfile(t, env.router, "/api/users/2")
+	assert.NotContains(t, profile, "phone_number")
+	var search contracts.ListUsersResponse
+	getJSON(t, env.router, "/api/users/search?q=other", &search)
+	assert.Empty(t, search.Users[0].PhoneNumber)
+
+	_, err := env.followRepo.Follow(1, 2)
+	assert.NoError(t, err)
+	_, profile = getPublicProfile(t, env.router, "/api/users/2")
+	assert.Equal(t, "+01234567890", profile["phone_number"])
+	assert.NotContains(t, profile, "email")
+	getJSON(t, env.router, "/api/users/search?q=other", &search)
+	assert.Equal(t, "+01234567890", search.Users[0].PhoneNumber)
+	// lists of other users' follows are projected for the viewer too
+	_, err = env.followRepo.Follow(2, 3)
+	assert.NoError(t, err)
+	var followers contracts.ListUsersResponse
+	getJSON(t, env.router, "/api/users/3/followers", &followers)
+	assert.Equal(t, "+01234567890", followers.Users[0].PhoneNumber)
+
+	_, err = env.followRepo.Unfollow(1, 2)
+	assert.NoError(t, err)
+	_, profile = getPublicProfile(t, env.router, "/api/users/2")
+	assert.NotContains(t, profile, "phone_number")
+}
+
+func TestGetUserNotFound(t *testing.T) {
+	env := newTestEnv()
+
+	w, _ := getPublicProfile(t, env.router, "/api/users/42")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = getPublicProfile(t, env.router, "/api/users/by-username/nobody")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = getPublicProfile(t, env.router, "/api/users/abc")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateExtendedProfile(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "owner")
+	registerUser(t, env.router, "viewer")
+
+	w := authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		DisplayName: "Misha K.",
+		Bio:         "Writes Go, drinks tea",
+		Location:    "Moscow",
+		Website:     "https://example.com",
+		Links:       []string{"https://github.com/misha", "http://t.me/misha"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var profile contracts.PublicProfile
+	getJSON(t, env.router, "/api/users/by-username/owner", &profile)
+	assert.Equal(t, "Misha K.", profile.DisplayName)
+	assert.Equal(t, "Writes Go, drinks tea", profile.Bio)
+	assert.Equal(t, "Moscow", profile.Location)
+	assert.Equal(t, "https://example.com", profile.Website)
+	assert.Equal(t, []string{"https://github.com/misha", "http://t.me/misha"}, profile.Links)
+
+	// links are replaced as a whole, other fields stay
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		Links: []string{"https://mastodon.social/@misha"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	user, _ := env.userRepo.FindByID(1)
+	assert.Equal(t, []string{"https://mastodon.social/@misha"}, user.Links)
+	assert.Equal(t, "Misha K.", user.DisplayName)
+}
+
+func TestUpdateProfileClearsFields(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "owner")
+	birthDate := time.Date(2004, 12, 11, 0, 0, 0, 0, time.UTC)
+	w := authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		FirstName:   "Mikhail",
+		PhoneNumber: "+01234567890",
+		BirthDate:   &birthDate,
+		Bio:         "bio",
+		Links:       []string{"https://example.com"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// empty values still mean the field is not changed
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{Location: "Moscow"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	user, _ := env.userRepo.FindByID(1)
+	assert.Equal(t, "Mikhail", user.FirstName)
+	assert.Equal(t, "bio", user.Bio)
+
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		Clear: []string{"first_name", "phone_number", "birth_date", "bio", "links"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	user, _ = env.userRepo.FindByID(1)
+	assert.Empty(t, user.FirstName)
+	assert.Empty(t, user.PhoneNumber)
+	assert.Nil(t, user.BirthDate)
+	assert.Empty(t, user.Bio)
+	assert.Empty(t, user.Links)
+	assert.Equal(t, "Moscow", user.Location)
+
+	events := listOwnAuditEvents(t, env).Events
+	assert.Equal(t, models.AuditProfileUpdated, events[0].Type)
+	assert.Equal(t, "first_name, birth_date, phone_number, bio, links", events[0].Details)
+
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		Bio:   "new bio",
+		Clear: []string{"bio"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Field bio can't be both set and cleared")
+	// email can't be cleared
+	w = authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		Clear: []string{"email"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateProfileValidatesExtendedFields(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "owner")
+
+	for _, request := range []contracts.UpdateProfileRequest{
+		{Bio: strings.Repeat("a", 501)},
+		{DisplayName: strings.Repeat("a", 51)},
+		{Website: "javascript:alert(1)"},
+		{Website: "example.com"},
+		{Links: []string{"https://example.com", "ftp://example.com"}},
+		{Links: []string{"https://example.com", "https://example.com"}},
+		{Links: []string{""}},
+		{Links: []string{"https://a.com", "https://b.com", "https://c.com", "https://d.com", "https://e.com", "https://f.com"}},
+	} {
+		w := authorizedRequest(env.router, "PUT", "/api/users/profile", "", request)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	// the limit is in characters, not bytes
+	w := authorizedRequest(env.router, "PUT", "/api/users/profile", "", contracts.UpdateProfileRequest{
+		Bio: strings.Repeat("я", 500),
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"social-network/common/proto"
+	"social-network/user-service/contracts"
+	"social-network/user-service/handlers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockRemovesFollowsAndHidesProfile(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	registerUser(t, env.router, "other")
+	_, err := env.followRepo.Follow(1, 2)
+	assert.NoError(t, err)
+	_, err = env.followRepo.Follow(2, 1)
+	assert.NoError(t, err)
+
+	w := postJSON(env.router, "/api/users/2/block", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	following, _ := env.followRepo.IsFollowing(1, 2)
+	assert.False(t, following)
+	following, _ = env.followRepo.IsFollowing(2, 1)
+	assert.False(t, following)
+
+	// the blocker can't follow either until they unblock
+	w = postJSON(env.router, "/api/users/2/follow", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var blocked contracts.ListUsersResponse
+	w = getJSON(t, env.router, "/api/users/blocks", &blocked)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"other"}, usernames(blocked.Users))
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/2/block", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(env.router, "/api/users/2/follow", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBlockedUserCantSeeBlockerProfile(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	registerUser(t, env.router, "blocker")
+	assert.NoError(t, env.relationRepo.Block(2, 1))
+
+	var profile contracts.UserProfileResponse
+	w := getJSON(t, env.router, "/api/users/2", &profile)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = postJSON(env.router, "/api/users/2/follow", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestBlockerHiddenFromSearchAndFollows(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	registerUser(t, env.router, "blocker")
+	registerUser(t, env.router, "friend")
+	_, err := env.followRepo.Follow(2, 3)
+	assert.NoError(t, err)
+	_, err = env.followRepo.Follow(3, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, env.relationRepo.Block(2, 1))
+
+	w, found := searchUsers(t, env.router, "q=e")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"friend", "viewer"}, usernames(found.Users))
+	assert.Equal(t, int64(2), found.TotalCount)
+
+	var follows contracts.ListUsersResponse
+	w = getJSON(t, env.router, "/api/users/2/followers", &follows)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = getJSON(t, env.router, "/api/users/2/following", &follows)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = getJSON(t, env.router, "/api/users/3/followers", &follows)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, follows.Users)
+	assert.Equal(t, int64(0), follows.TotalCount)
+	w = getJSON(t, env.router, "/api/users/3/following", &follows)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, follows.Users)
+
+	var status contracts.FollowStatusResponse
+	w = getJSON(t, env.router, "/api/users/2/following/3", &status)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = getJSON(t, env.router, "/api/users/3/following/2", &status)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// once the block is lifted the follows are visible again
+	assert.NoError(t, env.relationRepo.Unblock(2, 1))
+	w = getJSON(t, env.router, "/api/users/3/followers", &follows)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"blocker"}, usernames(follows.Users))
+}
+
+func TestMute(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "viewer")
+	registerUser(t, env.router, "other")
+
+	w := postJSON(env.router, "/api/users/2/mute", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(env.router, "/api/users/1/mute", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var muted contracts.ListUsersResponse
+	getJSON(t, env.router, "/api/users/mutes", &muted)
+	assert.Equal(t, []string{"other"}, usernames(muted.Users))
+
+	// muting doesn't affect following
+	w = postJSON(env.router, "/api/users/2/follow", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/2/mute", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	getJSON(t, env.router, "/api/users/mutes", &muted)
+	assert.Empty(t, muted.Users)
+}
+
+func TestRelationsGRPC(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"user1", "user2", "user3", "user4"} {
+		registerUser(t, env.router, username)
+	}
+	assert.NoError(t, env.relationRepo.Block(1, 2))
+	assert.NoError(t, env.relationRepo.Block(3, 1))
+	assert.NoError(t, env.relationRepo.Mute(1, 4))
+	assert.NoError(t, env.relationRepo.Mute(4, 1))
+
+	handler := handlers.NewRelationGRPCHandler(env.relationRepo, env.moderation)
+	relations, err := handler.GetRelations(context.Background(), &proto.GetRelationsRequest{UserId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{2}, relations.BlockedIds)
+	assert.Equal(t, []uint64{3}, relations.BlockedByIds)
+	assert.Equal(t, []uint64{4}, relations.MutedIds)
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func authorizedRequest(router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var requestBody []byte
+	if body != nil {
+		requestBody, _ = json.Marshal(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// makeAdmin promotes the user and returns a fresh token carrying the new role.
+func makeAdmin(t *testing.T, env *testEnv, userID uint) string {
+	user, _ := env.userRepo.FindByID(userID)
+	adminRole, _ := env.roleRepo.FindByName(models.RoleAdmin)
+	assert.Nil(t, env.roleRepo.AssignRole(user, adminRole))
+	tokens, err := env.tokens.StartSession(user, auth.ClientInfo{})
+	assert.Nil(t, err)
+	return tokens.JwtToken
+}
+
+func TestRegisterAssignsDefaultRole(t *testing.T) {
+	env := newTestEnv()
+	auth := registerUser(t, env.router, "user")
+
+	assert.Equal(t, []string{models.RoleUser}, auth.User.RoleNames())
+
+	claims := &contracts.Claims{}
+	_, _, err := jwt.NewParser().ParseUnverified(auth.JwtToken, claims)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{models.RoleUser}, claims.Roles)
+}
+
+func TestGrantAndRevokeRole(t *testing.T) {
+	env := newTestEnv()
+	admin := registerUser(t, env.router, "admin")
+	user := registerUser(t, env.router, "user")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+
+	rolesPath := fmt.Sprintf("/api/admin/users/%d/roles", user.User.ID)
+
+	w := authorizedRequest(env.router, "POST", rolesPath, adminToken, contracts.GrantRoleRequest{Role: models.RoleModerator})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updatedUser models.User
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &updatedUser))
+	assert.True(t, updatedUser.HasRole(models.RoleModerator))
+
+	w = authorizedRequest(env.router, "POST", rolesPath, adminToken, contracts.GrantRoleRequest{Role: "unknown"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = authorizedRequest(env.router, "DELETE", rolesPath+"/"+models.RoleModerator, adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	userFromDB, _ := env.userRepo.FindByID(user.User.ID)
+	assert.False(t, userFromDB.HasRole(models.RoleModerator))
+
+	// the admin can't lock themselves out
+	w = authorizedRequest(env.router, "DELETE",
+		fmt.Sprintf("/api/admin/users/%d/roles/%s", admin.User.ID, models.RoleAdmin), adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminRoutesRequireAdminRole(t *testing.T) {
+	env := newTestEnv()
+	user := registerUser(t, env.router, "user")
+
+	w := authorizedRequest(env.router, "GET", "/api/admin/roles", user.JwtToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = authorizedRequest(env.router, "POST", fmt.Sprintf("/api/admin/users/%d/roles", user.User.ID),
+		user.JwtToken, contracts.GrantRoleRequest{Role: models.RoleAdmin})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	adminToken := makeAdmin(t, env, user.User.ID)
+	w = authorizedRequest(env.router, "GET", "/api/admin/roles", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var roles []models.Role
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &roles))
+	assert.Len(t, roles, len(models.DefaultRoles))
+}
+
+func TestRoleChangesApplyToIssuedTokens(t *testing.T) {
+	env := newTestEnv()
+	admin := registerUser(t, env.router, "admin")
+	other := registerUser(t, env.router, "other")
+	adminToken := makeAdmin(t, env, admin.User.ID)
+	otherToken := makeAdmin(t, env, other.User.ID)
+
+	w := authorizedRequest(env.router, "DELETE",
+		fmt.Sprintf("/api/admin/users/%d/roles/%s", other.User.ID, models.RoleAdmin), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the token still claims the admin role, but it was issued before the role was revoked
+	w = authorizedRequest(env.router, "GET", "/api/admin/roles", otherToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, introspect(t, env, otherToken).Active)
+
+	user, _ := env.userRepo.FindByID(other.User.ID)
+	tokens, err := env.tokens.StartSession(user, auth.ClientInfo{})
+	assert.NoError(t, err)
+	w = authorizedRequest(env.router, "GET", "/api/admin/roles", tokens.JwtToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, []string{models.RoleUser}, introspect(t, env, tokens.JwtToken).Roles)
+}
+
+func TestModeratorPermissions(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	moderator := registerUser(t, env.router, "moderator")
+	user, _ := env.userRepo.FindByID(moderator.User.ID)
+	moderatorRole, _ := env.roleRepo.FindByName(models.RoleModerator)
+	assert.NoError(t, env.roleRepo.AssignRole(user, moderatorRole))
+	tokens, err := env.tokens.StartSession(user, auth.ClientInfo{})
+	assert.NoError(t, err)
+
+	w := authorizedRequest(env.router, "POST", "/api/admin/users/1/suspend", tokens.JwtToken,
+		contracts.SuspendUserRequest{Reason: "flooding", Until: time.Now().Add(time.Hour)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = authorizedRequest(env.router, "GET", "/api/admin/users/1/moderation", tokens.JwtToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// moderators can't manage roles or read the audit log of others
+	w = authorizedRequest(env.router, "POST", fmt.Sprintf("/api/admin/users/%d/roles", moderator.User.ID),
+		tokens.JwtToken, contracts.GrantRoleRequest{Role: models.RoleAdmin})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = authorizedRequest(env.router, "GET", "/api/admin/security-events", tokens.JwtToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
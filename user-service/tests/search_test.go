@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"social-network/user-service/contracts"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func searchUsers(t *testing.T, router *gin.Engine, query string) (*httptest.ResponseRecorder, contracts.ListUsersResponse) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/users/search?"+query, nil)
+	router.ServeHTTP(w, req)
+
+	var response contracts.ListUsersResponse
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w, response
+}
+
+func usernames(profiles []contracts.PublicProfile) []string {
+	names := make([]string, len(profiles))
+	for i, profile := range profiles {
+		names[i] = profile.Username
+	}
+	return names
+}
+
+func TestSearchUsersRanking(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"xanna", "anna", "annabel", "bob"} {
+		registerUser(t, env.router, username)
+	}
+	bob, _ := env.userRepo.FindByUsername("bob")
+	bob.FirstName = "Anna"
+	assert.NoError(t, env.userRepo.UpdateUser(bob))
+
+	w, response := searchUsers(t, env.router, "q=ANNA")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(4), response.TotalCount)
+	assert.Equal(t, []string{"anna", "annabel", "bob", "xanna"}, usernames(response.Users))
+	// search results are public profiles
+	assert.Empty(t, response.Users[1].Email)
+}
+
+func TestSearchUsersPagination(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"user1", "user2", "user3"} {
+		registerUser(t, env.router, username)
+	}
+
+	w, response := searchUsers(t, env.router, "q=user&page=2&pageSize=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(3), response.TotalCount)
+	assert.Equal(t, []string{"user3"}, usernames(response.Users))
+}
+
+func TestSearchUsersEscapesWildcards(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w, response := searchUsers(t, env.router, "q="+url.QueryEscape("%"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, response.Users)
+
+	w, _ = searchUsers(t, env.router, "q=")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = searchUsers(t, env.router, "q=user&pageSize=1000")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSearchUsersByDisplayName(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "bob")
+	registerUser(t, env.router, "xmisha")
+	bob, _ := env.userRepo.FindByUsername("bob")
+	bob.DisplayName = "Misha the Builder"
+	assert.NoError(t, env.userRepo.UpdateUser(bob))
+
+	w, response := searchUsers(t, env.router, "q=misha")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"bob", "xmisha"}, usernames(response.Users))
+	assert.Equal(t, "Misha the Builder", response.Users[0].DisplayName)
+}
@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"social-network/common/proto"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func postJSON(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	requestBody, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func registerUser(t *testing.T, router *gin.Engine, username string) contracts.AuthResponse {
+	w := postJSON(router, "/api/auth/register", contracts.RegisterRequest{
+		Username: username,
+		Email:    username + "@email.com",
+		Password: "password",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response contracts.AuthResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// introspect validates the token the way the gateway does, over gRPC ValidateToken.
+func introspect(t *testing.T, env *testEnv, token string) contracts.IntrospectResponse {
+	response, err := newUserGRPCHandler(env).ValidateToken(context.Background(), &proto.ValidateTokenRequest{Token: token})
+	assert.NoError(t, err)
+	return contracts.IntrospectResponse{
+		Active:        response.Active,
+		TokenType:     response.TokenType,
+		UserID:        uint(response.UserId),
+		Username:      response.Username,
+		SessionID:     uint(response.SessionId),
+		Roles:         response.Roles,
+		EmailVerified: response.EmailVerified,
+		TokenVersion:  uint(response.TokenVersion),
+		Restricted:    response.Restricted,
+		Scopes:        response.Scopes,
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	env := newTestEnv()
+	router := env.router
+	auth := registerUser(t, router, "user")
+	assert.NotEmpty(t, auth.RefreshToken)
+
+	w := postJSON(router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var refreshed contracts.TokenPair
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.NotEmpty(t, refreshed.JwtToken)
+	assert.NotEqual(t, auth.RefreshToken, refreshed.RefreshToken)
+
+	response := introspect(t, env, refreshed.JwtToken)
+	assert.True(t, response.Active)
+	assert.Equal(t, auth.User.ID, response.UserID)
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	env := newTestEnv()
+	router := env.router
+	auth := registerUser(t, router, "user")
+
+	w := postJSON(router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var refreshed contracts.TokenPair
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+
+	// the old token is presented again, e.g. by an attacker
+	w = postJSON(router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the whole chain is revoked now
+	w = postJSON(router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: refreshed.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, introspect(t, env, refreshed.JwtToken).Active)
+	assert.False(t, introspect(t, env, auth.JwtToken).Active)
+}
+
+func TestConcurrentRefreshRevokesSession(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+
+	// another refresh of the same token rotates it between the lookup and the update
+	raced := false
+	err := env.db.Callback().Update().Before("gorm:update").Register("test:concurrent_refresh", func(tx *gorm.DB) {
+		if raced || tx.Statement.Table != "sessions" {
+			return
+		}
+		raced = true
+		tx.Exec("UPDATE sessions SET refresh_token_hash = ? WHERE user_id = ?", "rotated-concurrently", registered.User.ID)
+	})
+	assert.NoError(t, err)
+
+	w := postJSON(env.router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: registered.RefreshToken})
+	assert.True(t, raced)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Refresh token was already used")
+	assert.False(t, introspect(t, env, registered.JwtToken).Active)
+}
+
+func TestLogout(t *testing.T) {
+	env := newTestEnv()
+	router := env.router
+	auth := registerUser(t, router, "user")
+	assert.True(t, introspect(t, env, auth.JwtToken).Active)
+
+	w := postJSON(router, "/api/auth/logout", contracts.LogoutRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.False(t, introspect(t, env, auth.JwtToken).Active)
+	w = postJSON(router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: auth.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postJSON(router, "/api/auth/logout", contracts.LogoutRequest{RefreshToken: "unknown"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func loginFrom(t *testing.T, router *gin.Engine, username, userAgent, remoteAddr string) contracts.TokenPair {
+	body, _ := json.Marshal(contracts.LoginRequest{Username: username, Password: "password"})
+	req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response contracts.AuthResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.TokenPair
+}
+
+func listSessions(t *testing.T, router *gin.Engine, token string) []contracts.Session {
+	w := authorizedRequest(router, "GET", "/api/users/sessions", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response contracts.ListSessionsResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Sessions
+}
+
+func TestListSessions(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	registerUser(t, env.router, "other")
+	laptop := loginFrom(t, env.router, "user",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0", "198.51.100.4:40000")
+
+	sessions := listSessions(t, env.router, laptop.JwtToken)
+	assert.Len(t, sessions, 2)
+	assert.True(t, sessions[0].Current)
+	assert.Equal(t, "Firefox on Linux", sessions[0].DeviceName)
+	assert.Equal(t, "198.51.100.4", sessions[0].IP)
+	assert.Contains(t, sessions[0].UserAgent, "Firefox/125.0")
+	assert.False(t, sessions[0].LastSeenAt.IsZero())
+	assert.False(t, sessions[1].Current)
+	assert.Equal(t, "Unknown device", sessions[1].DeviceName)
+
+	// logged out sessions aren't listed
+	w := postJSON(env.router, "/api/auth/logout", contracts.LogoutRequest{RefreshToken: registered.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, listSessions(t, env.router, laptop.JwtToken), 1)
+
+	w = authorizedRequest(env.router, "GET", "/api/users/sessions", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRevokeSession(t *testing.T) {
+	env := newTestEnv()
+	phone := registerUser(t, env.router, "user")
+	other := registerUser(t, env.router, "other")
+	laptop := loginFrom(t, env.router, "user", "curl/8.5.0", "198.51.100.4:40000")
+	phoneSession := introspect(t, env, phone.JwtToken).SessionID
+
+	w := authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/sessions/%d", phoneSession), laptop.JwtToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, introspect(t, env, phone.JwtToken).Active)
+	w = postJSON(env.router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: phone.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, introspect(t, env, laptop.JwtToken).Active)
+
+	w = authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/sessions/%d", phoneSession), laptop.JwtToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	// sessions of other users can't be revoked
+	otherSession := introspect(t, env, other.JwtToken).SessionID
+	w = authorizedRequest(env.router, "DELETE", fmt.Sprintf("/api/users/sessions/%d", otherSession), laptop.JwtToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.True(t, introspect(t, env, other.JwtToken).Active)
+	w = authorizedRequest(env.router, "DELETE", "/api/users/sessions/abc", laptop.JwtToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	events := listOwnAuditEvents(t, env)
+	assert.Equal(t, models.AuditSessionRevoked, events.Events[0].Type)
+	assert.Equal(t, fmt.Sprintf("session %d", phoneSession), events.Events[0].Details)
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	env := newTestEnv()
+	first := registerUser(t, env.router, "user")
+	other := registerUser(t, env.router, "other")
+	second := loginFrom(t, env.router, "user", "curl/8.5.0", "198.51.100.4:40000")
+	current := loginFrom(t, env.router, "user", "curl/8.5.0", "198.51.100.5:40000")
+
+	w := authorizedRequest(env.router, "DELETE", "/api/users/sessions/others", current.JwtToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response contracts.RevokeOtherSessionsResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.RevokedCount)
+
+	assert.False(t, introspect(t, env, first.JwtToken).Active)
+	assert.False(t, introspect(t, env, second.JwtToken).Active)
+	assert.True(t, introspect(t, env, current.JwtToken).Active)
+	assert.True(t, introspect(t, env, other.JwtToken).Active)
+	sessions := listSessions(t, env.router, current.JwtToken)
+	assert.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Current)
+
+	w = authorizedRequest(env.router, "DELETE", "/api/users/sessions/others", current.JwtToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(0), response.RevokedCount)
+}
+
+func TestSessionLastSeen(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	sessionID := introspect(t, env, registered.JwtToken).SessionID
+	lastSeen := func() time.Time {
+		var session models.Session
+		assert.NoError(t, env.db.First(&session, sessionID).Error)
+		return session.LastSeenAt
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	assert.NoError(t, env.db.Model(&models.Session{}).Where("id = ?", sessionID).
+		Update("last_seen_at", hourAgo).Error)
+	introspect(t, env, registered.JwtToken)
+	assert.True(t, lastSeen().After(hourAgo.Add(59*time.Minute)))
+
+	assert.NoError(t, env.db.Model(&models.Session{}).Where("id = ?", sessionID).
+		Update("last_seen_at", hourAgo).Error)
+	w := postJSON(env.router, "/api/auth/refresh", contracts.RefreshRequest{RefreshToken: registered.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, lastSeen().After(hourAgo.Add(59*time.Minute)))
+}
+
+func TestDeviceName(t *testing.T) {
+	for userAgent, expected := range map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36":                         "Chrome on Windows",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0":           "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1": "Safari on iPhone",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15":                      "Safari on macOS",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36":                   "Chrome on Android",
+		"curl/8.5.0": "curl",
+		"":           "Unknown device",
+	} {
+		assert.Equal(t, expected, auth.DeviceName(userAgent), userAgent)
+	}
+}
@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"social-network/user-service/auth"
+	"social-network/user-service/contracts"
+	"social-network/user-service/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func totpCode(t *testing.T, secret string, now time.Time) string {
+	code, err := auth.TOTPCode(secret, now)
+	assert.NoError(t, err)
+	return code
+}
+
+// enableTwoFactor registers the user with id 1 and turns 2FA on, it returns the secret and recovery codes.
+func enableTwoFactor(t *testing.T, env *testEnv, now time.Time) (string, []string) {
+	registerUser(t, env.router, "user")
+
+	w := postJSON(env.router, "/api/users/2fa/enroll", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enrollResponse contracts.EnrollTwoFactorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollResponse))
+	assert.True(t, strings.HasPrefix(enrollResponse.ProvisioningURI, "otpauth://totp/"))
+	assert.Contains(t, enrollResponse.ProvisioningURI, "secret="+enrollResponse.Secret)
+
+	w = postJSON(env.router, "/api/users/2fa/confirm",
+		contracts.TwoFactorCodeRequest{Code: totpCode(t, enrollResponse.Secret, now)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var codesResponse contracts.RecoveryCodesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &codesResponse))
+	assert.Len(t, codesResponse.RecoveryCodes, 10)
+	return enrollResponse.Secret, codesResponse.RecoveryCodes
+}
+
+func loginChallenge(t *testing.T, env *testEnv) contracts.TwoFactorChallengeResponse {
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var challenge contracts.TwoFactorChallengeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	assert.True(t, challenge.TwoFactorRequired)
+	assert.NotEmpty(t, challenge.ChallengeToken)
+	assert.NotContains(t, w.Body.String(), "jwt_token")
+	return challenge
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	secret, _ := enableTwoFactor(t, env, now)
+
+	challenge := loginChallenge(t, env)
+	now = now.Add(30 * time.Second)
+	w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           totpCode(t, secret, now),
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var authResponse contracts.AuthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &authResponse))
+	assert.NotEmpty(t, authResponse.JwtToken)
+	assert.True(t, authResponse.User.TwoFactorEnabled)
+
+	// a challenge can be used only once
+	w = postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           totpCode(t, secret, now.Add(30*time.Second)),
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestTwoFactorCodeCannotBeReplayed(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	secret, _ := enableTwoFactor(t, env, now)
+
+	// the code used for confirmation is spent
+	challenge := loginChallenge(t, env)
+	w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           totpCode(t, secret, now),
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestTwoFactorChallengeExpires(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	secret, _ := enableTwoFactor(t, env, now)
+
+	challenge := loginChallenge(t, env)
+	now = now.Add(2 * time.Minute)
+	w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           totpCode(t, secret, now),
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "challenge")
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	_, recoveryCodes := enableTwoFactor(t, env, now)
+
+	challenge := loginChallenge(t, env)
+	w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           strings.ToUpper(recoveryCodes[0]),
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// every recovery code works once
+	challenge = loginChallenge(t, env)
+	w = postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           recoveryCodes[0],
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postJSON(env.router, "/api/users/2fa/recovery-codes", contracts.TwoFactorCodeRequest{Code: recoveryCodes[1]})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var codesResponse contracts.RecoveryCodesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &codesResponse))
+	assert.Len(t, codesResponse.RecoveryCodes, 10)
+	assert.Equal(t, models.AuditRecoveryCodesRegenerated, listOwnAuditEvents(t, env).Events[0].Type)
+
+	// old codes are replaced
+	w = postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           recoveryCodes[2],
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           codesResponse.RecoveryCodes[0],
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTwoFactorWrongCodesLockAccount(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	secret, _ := enableTwoFactor(t, env, now)
+
+	challenge := loginChallenge(t, env)
+	for i := 0; i < 3; i++ {
+		w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+			ChallengeToken: challenge.ChallengeToken,
+			Code:           "000000",
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           totpCode(t, secret, now.Add(30*time.Second)),
+	})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestPasswordLoginDoesNotResetTwoFactorFailures(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	enableTwoFactor(t, env, now)
+
+	// a fresh challenge after every wrong code doesn't give more guesses
+	for i := 0; i < 3; i++ {
+		challenge := loginChallenge(t, env)
+		w := postJSON(env.router, "/api/auth/login/2fa", contracts.LoginTwoFactorRequest{
+			ChallengeToken: challenge.ChallengeToken,
+			Code:           "000000",
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w := postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	env := newTestEnv()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	env.twoFactor.Now = func() time.Time { return now }
+	secret, _ := enableTwoFactor(t, env, now)
+
+	w := postJSON(env.router, "/api/users/2fa/enroll", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = postJSON(env.router, "/api/users/2fa/disable", contracts.TwoFactorCodeRequest{Code: "123456"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	now = now.Add(30 * time.Second)
+	w = postJSON(env.router, "/api/users/2fa/disable", contracts.TwoFactorCodeRequest{Code: totpCode(t, secret, now)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{models.AuditTwoFactorDisabled, models.AuditTwoFactorEnabled},
+		eventTypes(listOwnAuditEvents(t, env).Events)[:2])
+
+	w = postJSON(env.router, "/api/auth/login", contracts.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "jwt_token")
+}
+
+func TestTwoFactorConfirmRequiresValidCode(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+
+	w := postJSON(env.router, "/api/users/2fa/confirm", contracts.TwoFactorCodeRequest{Code: "123456"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	postJSON(env.router, "/api/users/2fa/enroll", nil)
+	w = postJSON(env.router, "/api/users/2fa/confirm", contracts.TwoFactorCodeRequest{Code: "abcdef"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	user, err := env.userRepo.FindByID(1)
+	assert.NoError(t, err)
+	assert.False(t, user.TwoFactorEnabled)
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test vector for SHA1, the secret is "12345678901234567890" in base32
+	code, err := auth.TOTPCode("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"social-network/common/proto"
+	"social-network/user-service/contracts"
+	"social-network/user-service/handlers"
+	"social-network/user-service/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func newUserGRPCHandler(env *testEnv) *handlers.UserGRPCHandler {
+	return handlers.NewUserGRPCHandler(
+		env.userRepo, env.relationRepo, env.followRepo, env.tokens, env.accessTokens)
+}
+
+func TestUserGRPCGetUser(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user1")
+	registerUser(t, env.router, "user2")
+	handler := newUserGRPCHandler(env)
+
+	user, err := handler.GetUser(context.Background(), &proto.GetUserRequest{Id: 2, ViewerId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", user.Username)
+	assert.Empty(t, user.Email)
+	assert.NotNil(t, user.CreatedAt)
+
+	user, err = handler.GetUser(context.Background(), &proto.GetUserRequest{Id: 2, ViewerId: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "user2@email.com", user.Email)
+
+	user, err = handler.GetUserByUsername(context.Background(), &proto.GetUserByUsernameRequest{Username: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), user.Id)
+
+	_, err = handler.GetUser(context.Background(), &proto.GetUserRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = handler.GetUserByUsername(context.Background(), &proto.GetUserByUsernameRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	assert.NoError(t, env.relationRepo.Block(2, 1))
+	_, err = handler.GetUser(context.Background(), &proto.GetUserRequest{Id: 2, ViewerId: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = handler.GetUser(context.Background(), &proto.GetUserRequest{Id: 2})
+	assert.NoError(t, err)
+}
+
+func TestUserGRPCDisplayNameAndAvatar(t *testing.T) {
+	env := newTestEnv()
+	registerUser(t, env.router, "user")
+	w := authorizedRequest(env.router, "PUT", "/api/users/profile", "",
+		contracts.UpdateProfileRequest{DisplayName: "Misha K."})
+	assert.Equal(t, http.StatusOK, w.Code)
+	user, _ := env.userRepo.FindByID(1)
+	assert.NoError(t, env.userRepo.UpdateAvatar(user, "v1"))
+
+	response, err := newUserGRPCHandler(env).BatchGetUsers(context.Background(),
+		&proto.BatchGetUsersRequest{Ids: []uint64{1}, ViewerId: 2})
+	assert.NoError(t, err)
+	// the gateway gets the user over the wire, so the map has to survive encoding
+	data, err := protobuf.Marshal(response)
+	assert.NoError(t, err)
+	var decoded proto.BatchGetUsersResponse
+	assert.NoError(t, protobuf.Unmarshal(data, &decoded))
+	if assert.Len(t, decoded.Users, 1) {
+		assert.Equal(t, "Misha K.", decoded.Users[0].DisplayName)
+		assert.Equal(t, models.AvatarURLs(1, "v1"), decoded.Users[0].AvatarUrls)
+	}
+}
+
+func TestUserGRPCBatchGetUsers(t *testing.T) {
+	env := newTestEnv()
+	for _, username := range []string{"user1", "user2", "user3"} {
+		registerUser(t, env.router, username)
+	}
+	assert.NoError(t, env.relationRepo.Block(3, 1))
+	handler := newUserGRPCHandler(env)
+
+	response, err := handler.BatchGetUsers(context.Background(), &proto.BatchGetUsersRequest{Ids: []uint64{3, 2, 42, 1}, ViewerId: 1})
+	assert.NoError(t, err)
+	var ids []uint64
+	for _, user := range response.Users {
+		ids = append(ids, user.Id)
+	}
+	assert.Equal(t, []uint64{1, 2}, ids)
+	assert.Equal(t, "user1@email.com", response.Users[0].Email)
+	assert.Empty(t, response.Users[1].Email)
+
+	// followers-only fields of embedded users are shown to followers only
+	user2, _ := env.userRepo.FindByID(2)
+	user2.EmailVisibility = models.VisibilityFollowers
+	assert.NoError(t, env.userRepo.UpdateUser(user2))
+	response, err = handler.BatchGetUsers(context.Background(), &proto.BatchGetUsersRequest{Ids: []uint64{2}, ViewerId: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, response.Users[0].Email)
+	_, err = env.followRepo.Follow(1, 2)
+	assert.NoError(t, err)
+	response, err = handler.BatchGetUsers(context.Background(), &proto.BatchGetUsersRequest{Ids: []uint64{2}, ViewerId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "user2@email.com", response.Users[0].Email)
+	user, err := handler.GetUser(context.Background(), &proto.GetUserRequest{Id: 2, ViewerId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "user2@email.com", user.Email)
+	response, err = handler.BatchGetUsers(context.Background(), &proto.BatchGetUsersRequest{Ids: []uint64{2}})
+	assert.NoError(t, err)
+	assert.Empty(t, response.Users[0].Email)
+
+	response, err = handler.BatchGetUsers(context.Background(), &proto.BatchGetUsersRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, response.Users)
+
+	_, err = handler.BatchGetUsers(context.Background(), &proto.BatchGetUsersRequest{Ids: make([]uint64, 101)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserGRPCValidateToken(t *testing.T) {
+	env := newTestEnv()
+	registered := registerUser(t, env.router, "user")
+	handler := newUserGRPCHandler(env)
+
+	validation, err := handler.ValidateToken(context.Background(), &proto.ValidateTokenRequest{Token: registered.JwtToken})
+	assert.NoError(t, err)
+	assert.True(t, validation.Active)
+	assert.Equal(t, contracts.TokenTypeAccess, validation.TokenType)
+	assert.Equal(t, uint64(1), validation.UserId)
+	assert.Equal(t, "user", validation.Username)
+	assert.NotZero(t, validation.SessionId)
+
+	created := createAccessToken(t, env, contracts.CreateAccessTokenRequest{
+		Name:   "bot",
+		Scopes: []string{models.ScopePostsRead},
+	})
+	validation, err = handler.ValidateToken(context.Background(), &proto.ValidateTokenRequest{Token: created.Token})
+	assert.NoError(t, err)
+	assert.True(t, validation.Active)
+	assert.Equal(t, contracts.TokenTypePersonalAccess, validation.TokenType)
+	assert.Equal(t, []string{models.ScopePostsRead}, validation.Scopes)
+	assert.Empty(t, validation.Roles)
+
+	validation, err = handler.ValidateToken(context.Background(), &proto.ValidateTokenRequest{Token: "garbage"})
+	assert.NoError(t, err)
+	assert.False(t, validation.Active)
+}
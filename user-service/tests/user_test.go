@@ -2,35 +2,219 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha1"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"social-network/common/proto"
+	"social-network/user-service/accounts"
+	"social-network/user-service/auth"
+	"social-network/user-service/avatars"
 	"social-network/user-service/contracts"
 	"social-network/user-service/handlers"
+	"social-network/user-service/middleware"
 	"social-network/user-service/models"
+	"social-network/user-service/notifier"
+	"social-network/user-service/passwords"
 	"social-network/user-service/repositories"
+	"social-network/user-service/storage"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+type testEnv struct {
+	router       *gin.Engine
+	db           *gorm.DB
+	userRepo     *repositories.UserRepository
+	roleRepo     *repositories.RoleRepository
+	tokens       *auth.TokenManager
+	keyRing      *auth.KeyRing
+	accessTokens *auth.AccessTokenManager
+	notifier     *notifier.FileNotifier
+	loginGuard   *auth.LoginGuard
+	twoFactor    *auth.TwoFactorManager
+	followRepo   *repositories.FollowRepository
+	relationRepo *repositories.RelationRepository
+	deleter      *accounts.Deleter
+	posts        *fakePostEraser
+	avatarsDir   string
+	moderation   *repositories.ModerationRepository
+	audit        *repositories.AuditRepository
+	inviter      *accounts.Inviter
+}
+
+// fakePostEraser records the erase requests sent to post-service.
+type fakePostEraser struct {
+	requests []*proto.EraseUserPostsRequest
+	affected int64
+	err      error
+}
+
+func (f *fakePostEraser) EraseUserPosts(
+	_ context.Context, in *proto.EraseUserPostsRequest, _ ...grpc.CallOption) (*proto.EraseUserPostsResponse, error) {
+	f.requests = append(f.requests, in)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &proto.EraseUserPostsResponse{AffectedCount: f.affected}, nil
+}
+
+// testArgon2idParams keep hashing cheap, the production defaults would slow every test down.
+func testArgon2idParams() passwords.Argon2idParams {
+	return passwords.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func newTestHasher(params passwords.Argon2idParams) *passwords.Hasher {
+	return passwords.NewHasher(passwords.NewArgon2id(params), passwords.NewBcrypt(bcrypt.MinCost))
+}
+
+// testInternalToken guards the /internal endpoints of every test env.
+const testInternalToken = "internal-token"
+
+// breachedPasswords are in the corpus of every test env, the passwords used by the tests aren't.
+var breachedPasswords = []string{"qwerty123", "iloveyou", "P@ssw0rd!"}
+
+func newTestBreachedList(dir string) *passwords.BreachedList {
+	lines := []string{"# test corpus"}
+	for _, password := range breachedPasswords {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%X:42", sum))
+	}
+	path := filepath.Join(dir, "breached.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		panic(err)
+	}
+	list, err := passwords.LoadBreachedList(path)
+	if err != nil {
+		panic(err)
+	}
+	return list
+}
+
 func fixture() (*gin.Engine, *repositories.UserRepository) {
+	env := newTestEnv()
+	return env.router, env.userRepo
+}
+
+func newTestEnv() *testEnv {
+	notificationsDir, err := os.MkdirTemp("", "notifications")
+	if err != nil {
+		panic(err)
+	}
+	fileNotifier := notifier.NewFileNotifier(filepath.Join(notificationsDir, "notifications.jsonl"))
+
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	err := db.AutoMigrate(&models.User{})
+	err = db.AutoMigrate(
+		&models.Role{}, &models.Permission{}, &models.User{},
+		&models.Session{}, &models.RotatedRefreshToken{},
+		&models.PasswordResetToken{}, &models.EmailVerificationToken{},
+		&models.LoginFailure{}, &models.AccountLockout{},
+		&models.TwoFactor{}, &models.RecoveryCode{}, &models.LoginChallenge{},
+		&models.Follow{}, &models.Block{}, &models.Mute{},
+		&models.PersonalAccessToken{}, &models.AccountDeletion{}, &models.ModerationAction{},
+		&models.AuditEvent{}, &models.InviteCode{})
 	if err != nil {
 		panic(err)
 	}
 
-	userRepo := repositories.NewUserRepository(db)
-	userHandler := handlers.NewUserHandler(userRepo, "test_secret_key")
+	userRepo := repositories.NewUserRepository(db, newTestHasher(testArgon2idParams()))
+	sessionRepo := repositories.NewSessionRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	if err = userRepo.EnsureSearchIndexes(); err != nil {
+		panic(err)
+	}
+	if err = roleRepo.EnsureRoles(models.DefaultRoles); err != nil {
+		panic(err)
+	}
+	signingKey, err := auth.GenerateSigningKey()
+	if err != nil {
+		panic(err)
+	}
+	keyRing := auth.NewKeyRing(signingKey, time.Minute)
+	tokens := auth.NewTokenManager(keyRing, userRepo, sessionRepo, time.Minute, time.Hour)
+	emailVerifier := auth.NewEmailVerifier(repositories.NewEmailVerificationRepository(db), fileNotifier, time.Hour)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	loginGuard := auth.NewLoginGuard(loginAttemptRepo, auth.LoginGuardConfig{
+		MaxAccountFailures: 3,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: time.Hour,
+		MaxIPFailures:      10,
+		FailureWindow:      time.Hour,
+	})
+	followRepo := repositories.NewFollowRepository(db)
+	relationRepo := repositories.NewRelationRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	twoFactor := auth.NewTwoFactorManager(
+		repositories.NewTwoFactorRepository(db), userRepo, "social-network", time.Minute)
+	avatarsDir, err := os.MkdirTemp("", "avatars")
+	if err != nil {
+		panic(err)
+	}
+	avatarService := avatars.NewService(storage.NewLocalBlobStore(avatarsDir), 1<<20)
+	posts := &fakePostEraser{}
+	deleter := accounts.NewDeleter(
+		repositories.NewAccountDeletionRepository(db), posts, avatarService, 24*time.Hour, false)
+	passwordPolicy := passwords.NewPolicy(
+		passwords.PolicyConfig{MinLength: 6, MinCharacterClasses: 1}, newTestBreachedList(notificationsDir))
+	inviter := accounts.NewInviter(repositories.NewInviteRepository(db), false)
+	userHandler := handlers.NewUserHandler(
+		userRepo, roleRepo, tokens, emailVerifier, loginGuard, twoFactor, followRepo, relationRepo, auditRepo,
+		passwordPolicy, inviter)
+	twoFactorHandler := handlers.NewTwoFactorHandler(userRepo, twoFactor, tokens, loginGuard, auditRepo)
+	followHandler := handlers.NewFollowHandler(userRepo, followRepo, relationRepo)
+	relationHandler := handlers.NewRelationHandler(userRepo, relationRepo, followRepo)
+	lockoutHandler := handlers.NewLockoutHandler(userRepo, loginAttemptRepo, loginGuard)
+	accountDeletionHandler := handlers.NewAccountDeletionHandler(userRepo, deleter, auditRepo)
+	dataExportHandler := handlers.NewDataExportHandler(userRepo, repositories.NewDataExportRepository(db))
+	accessTokens := auth.NewAccessTokenManager(repositories.NewAccessTokenRepository(db))
+	accessTokenHandler := handlers.NewAccessTokenHandler(userRepo, accessTokens, auditRepo)
+	sessionHandler := handlers.NewSessionHandler(tokens, accessTokens, userRepo, auditRepo)
+	emailHandler := handlers.NewEmailHandler(userRepo, emailVerifier)
+	roleHandler := handlers.NewRoleHandler(userRepo, roleRepo)
+	passwordHandler := handlers.NewPasswordHandler(
+		userRepo, repositories.NewPasswordResetRepository(db), tokens, fileNotifier, time.Hour, auditRepo,
+		passwordPolicy)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	inviteHandler := handlers.NewInviteHandler(userRepo, inviter)
+	avatarHandler := handlers.NewAvatarHandler(userRepo, avatarService)
+	moderationRepo := repositories.NewModerationRepository(db)
+	moderationHandler := handlers.NewModerationHandler(
+		userRepo, moderationRepo, accounts.NewModerator(moderationRepo, tokens))
 
 	router := gin.Default()
+	router.GET("/.well-known/jwks.json", handlers.NewKeysHandler(keyRing).JWKS)
 	router.POST("/api/auth/register", userHandler.Register)
 	router.POST("/api/auth/login", userHandler.Login)
+	router.POST("/api/auth/login/2fa", twoFactorHandler.LoginTwoFactor)
+	router.POST("/api/auth/refresh", sessionHandler.Refresh)
+	router.POST("/api/auth/logout", sessionHandler.Logout)
+	router.POST("/api/auth/password/forgot", passwordHandler.ForgotPassword)
+	router.POST("/api/auth/password/reset", passwordHandler.ResetPassword)
+	router.POST("/api/auth/verify-email", emailHandler.VerifyEmail)
+	router.GET("/api/users/avatars/:userId/:file", avatarHandler.GetAvatar)
+
+	internalUsers := router.Group("/internal/users")
+	internalUsers.Use(middleware.RequireInternalToken(testInternalToken))
+	internalUsers.GET("/:id/export", dataExportHandler.ExportUserData)
+
+	// sessions need the id of the current session, so they are behind the real middleware
+	sessions := router.Group("/api/users/sessions")
+	sessions.Use(middleware.AuthMiddleware(tokens))
+	sessions.GET("", sessionHandler.ListSessions)
+	sessions.DELETE("/others", sessionHandler.RevokeOtherSessions)
+	sessions.DELETE("/:sessionId", sessionHandler.RevokeSession)
 
 	auth := router.Group("/api/users")
 	auth.Use(func(c *gin.Context) {
@@ -39,8 +223,76 @@ func fixture() (*gin.Engine, *repositories.UserRepository) {
 	})
 	auth.GET("/profile", userHandler.GetProfile)
 	auth.PUT("/profile", userHandler.UpdateProfile)
-
-	return router, userRepo
+	auth.PUT("/profile/avatar", avatarHandler.UploadAvatar)
+	auth.DELETE("/profile/avatar", avatarHandler.DeleteAvatar)
+	auth.PUT("/password", passwordHandler.ChangePassword)
+	auth.GET("/security-events", auditHandler.ListOwnEvents)
+	auth.GET("/tokens", accessTokenHandler.ListTokens)
+	auth.POST("/tokens", accessTokenHandler.CreateToken)
+	auth.DELETE("/tokens/:tokenId", accessTokenHandler.RevokeToken)
+	auth.GET("/invites", inviteHandler.ListInvites)
+	auth.POST("/invites", inviteHandler.CreateInvite)
+	auth.DELETE("/invites/:inviteId", inviteHandler.RevokeInvite)
+	auth.DELETE("/profile", accountDeletionHandler.DeleteAccount)
+	auth.GET("/profile/deletion", accountDeletionHandler.GetDeletion)
+	auth.POST("/profile/deletion/cancel", accountDeletionHandler.CancelDeletion)
+	auth.GET("/search", userHandler.SearchUsers)
+	auth.GET("/:id", userHandler.GetUser)
+	auth.GET("/by-username/:username", userHandler.GetUserByUsername)
+	auth.POST("/:id/follow", followHandler.Follow)
+	auth.DELETE("/:id/follow", followHandler.Unfollow)
+	auth.GET("/:id/followers", followHandler.ListFollowers)
+	auth.GET("/:id/following", followHandler.ListFollowing)
+	auth.GET("/:id/following/:targetId", followHandler.IsFollowing)
+	auth.GET("/blocks", relationHandler.ListBlocked)
+	auth.POST("/:id/block", relationHandler.Block)
+	auth.DELETE("/:id/block", relationHandler.Unblock)
+	auth.GET("/mutes", relationHandler.ListMuted)
+	auth.POST("/:id/mute", relationHandler.Mute)
+	auth.DELETE("/:id/mute", relationHandler.Unmute)
+	auth.POST("/verify-email/resend", emailHandler.ResendVerification)
+	auth.POST("/2fa/enroll", twoFactorHandler.Enroll)
+	auth.POST("/2fa/confirm", twoFactorHandler.Confirm)
+	auth.POST("/2fa/disable", twoFactorHandler.Disable)
+	auth.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware(tokens))
+	manageRoles := middleware.RequirePermission(roleRepo, models.PermissionManageRoles)
+	moderateUsers := middleware.RequirePermission(roleRepo, models.PermissionModerateUsers)
+	requireAdmin := middleware.RequireRoles(models.RoleAdmin)
+	admin.GET("/roles", manageRoles, roleHandler.ListRoles)
+	admin.POST("/users/:id/roles", manageRoles, roleHandler.GrantRole)
+	admin.DELETE("/users/:id/roles/:role", manageRoles, roleHandler.RevokeRole)
+	admin.POST("/users/:id/unlock", requireAdmin, lockoutHandler.UnlockUser)
+	admin.GET("/lockouts", requireAdmin, lockoutHandler.ListLockouts)
+	admin.POST("/users/:id/suspend", moderateUsers, moderationHandler.SuspendUser)
+	admin.POST("/users/:id/ban", moderateUsers, moderationHandler.BanUser)
+	admin.POST("/users/:id/reinstate", moderateUsers, moderationHandler.ReinstateUser)
+	admin.GET("/users/:id/moderation", moderateUsers, moderationHandler.GetModeration)
+	admin.GET("/users/:id/invites", requireAdmin, inviteHandler.GetUserInvites)
+	admin.GET("/security-events", requireAdmin, auditHandler.ListEvents)
+
+	return &testEnv{
+		router:       router,
+		db:           db,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		tokens:       tokens,
+		keyRing:      keyRing,
+		accessTokens: accessTokens,
+		notifier:     fileNotifier,
+		loginGuard:   loginGuard,
+		twoFactor:    twoFactor,
+		followRepo:   followRepo,
+		relationRepo: relationRepo,
+		deleter:      deleter,
+		posts:        posts,
+		avatarsDir:   avatarsDir,
+		moderation:   moderationRepo,
+		audit:        auditRepo,
+		inviter:      inviter,
+	}
 }
 
 func TestRegister(t *testing.T) {